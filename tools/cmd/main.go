@@ -1,13 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"mcpserver/internal/auth"
 	"mcpserver/internal/config"
 	"mcpserver/internal/handler"
+	"mcpserver/internal/mcp"
+	"mcpserver/internal/models"
 	"mcpserver/internal/service"
 	"mcpserver/internal/storage"
+	"mcpserver/pkg/archive"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +28,7 @@ type Server struct {
 	Config   *config.Config
 	Services *Services
 	Handlers *Handlers
+	MCP      *mcp.Server
 }
 
 type Services struct {
@@ -29,9 +42,22 @@ type Handlers struct {
 	VectorSearch *handler.VectorSearchHandler
 	RepoIndexer  *handler.RepoIndexerHandler
 	MCP          *handler.MCPHandler
+	MCPProtocol  *handler.MCPProtocolHandler
 }
 
 func main() {
+	stdio := flag.Bool("stdio", false, "serve the MCP protocol over stdin/stdout instead of HTTP")
+	flag.Parse()
+
+	// In stdio mode stdout carries protocol messages only. The service layer
+	// logs with fmt.Printf, so point os.Stdout at stderr and keep the real
+	// stdout for the transport.
+	protocolOut := os.Stdout
+	if *stdio {
+		os.Stdout = os.Stderr
+		log.SetOutput(os.Stderr)
+	}
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Warning: Error loading .env file: %v", err)
@@ -43,8 +69,25 @@ func main() {
 		log.Fatalf("Failed to initialize server: %v", err)
 	}
 
+	if *stdio {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// The stdio client is whoever launched the process; the access
+		// policy can still restrict it through the "stdio" principal
+		ctx = auth.WithPrincipal(ctx, &auth.Principal{Name: "stdio"})
+
+		if err := server.MCP.ServeStdio(ctx, os.Stdin, protocolOut); err != nil && !errors.Is(err, context.Canceled) {
+			log.Fatalf("MCP stdio transport failed: %v", err)
+		}
+		return
+	}
+
 	// Setup routes
-	router := setupRoutes(server.Handlers)
+	router, err := setupMiddleware(server.Config, setupRoutes(server.Handlers))
+	if err != nil {
+		log.Fatalf("Failed to configure authentication: %v", err)
+	}
 
 	// Start server
 	log.Printf("MCP Server starting on port %s...", server.Config.Port)
@@ -58,40 +101,148 @@ func initializeServer() (*Server, error) {
 	cfg := config.Load()
 
 	// Initialize storage layer
-	pineconeStore, err := storage.NewPineconeStore(
-		cfg.PineconeAPIKey,
-		cfg.PineconeEnvironment,
-		cfg.PineconeIndexName,
-		cfg.PineconeHost,
-	)
+	vectorStore, err := newVectorStore(cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	openaiClient := storage.NewOpenAIClient(cfg.OpenAIAPIKey)
 
+	embedder, err := newEmbedder(cfg, openaiClient)
+	if err != nil {
+		return nil, err
+	}
+
+	indexState, err := storage.NewIndexStateStore(cfg.IndexStatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := storage.NewCatalogStore(cfg.CatalogPath)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := auth.LoadPolicy(cfg.AccessPolicyPath)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		log.Printf("No access policy configured; every caller may search and index any repository")
+	}
+
+	credentials, err := newCredentialStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	indexRules, err := service.LoadIndexRules(cfg.IndexRulesPath)
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize services
+	vectorSearch := service.NewVectorSearchService(vectorStore, embedder, openaiClient, policy)
+	repoIndexer := service.NewRepoIndexerService(vectorStore, embedder, indexState, catalog, credentials, policy, service.IndexerOptions{
+		EmbeddingBatchSize: cfg.EmbeddingBatchSize,
+		UpsertBatchSize:    cfg.UpsertBatchSize,
+		CredentialHosts:    cfg.CredentialHosts,
+		AllowedRoots:       cfg.IndexAllowedRoots,
+		ArchiveLimits: archive.Limits{
+			MaxFiles: cfg.ArchiveMaxFiles,
+			MaxBytes: int64(cfg.ArchiveMaxMB) << 20,
+		},
+		Filter: models.IndexFilter{
+			Exclude:     cfg.IndexExclude,
+			MaxFileSize: int64(cfg.MaxFileSize),
+		},
+		RepositoryFilters: indexRules,
+		ChunkOverlap:      cfg.ChunkOverlapLines,
+		ChunkSizing:       cfg.ChunkSizing,
+		ChunkMaxTokens:    cfg.ChunkMaxTokens,
+		ChunkMaxBytes:     cfg.ChunkMaxBytes,
+	})
+
 	services := &Services{
-		VectorSearch: service.NewVectorSearchService(pineconeStore, openaiClient),
-		RepoIndexer:  service.NewRepoIndexerService(pineconeStore, openaiClient),
-		MCPServer:    service.NewMCPServerService(pineconeStore, openaiClient),
+		VectorSearch: vectorSearch,
+		RepoIndexer:  repoIndexer,
+		MCPServer:    service.NewMCPServerService(vectorSearch, repoIndexer, credentials, policy),
 	}
 
+	// Initialize the native MCP protocol server
+	serverInfo := services.MCPServer.GetServerInfo()
+	mcpServer := mcp.NewServer(mcp.Implementation{Name: serverInfo.Name, Version: serverInfo.Version})
+	mcp.RegisterServiceTools(mcpServer, services.VectorSearch, services.RepoIndexer, services.MCPServer)
+	mcp.RegisterServiceResources(mcpServer, services.RepoIndexer)
+	mcpSessions := mcp.NewSessionStore(time.Duration(cfg.MCPSessionTimeout) * time.Minute)
+
 	// Initialize handlers
 	handlers := &Handlers{
 		Health:       handler.NewHealthHandler(),
 		VectorSearch: handler.NewVectorSearchHandler(services.VectorSearch),
-		RepoIndexer:  handler.NewRepoIndexerHandler(services.RepoIndexer),
+		RepoIndexer:  handler.NewRepoIndexerHandler(services.RepoIndexer, int64(cfg.MaxUploadMB)<<20),
 		MCP:          handler.NewMCPHandler(services.MCPServer),
+		MCPProtocol:  handler.NewMCPProtocolHandler(mcpServer, mcpSessions),
 	}
 
 	return &Server{
 		Config:   cfg,
 		Services: services,
 		Handlers: handlers,
+		MCP:      mcpServer,
 	}, nil
 }
 
+func newVectorStore(cfg *config.Config) (storage.VectorStore, error) {
+	switch cfg.VectorStore {
+	case "pinecone":
+		return storage.NewPineconeStore(
+			cfg.PineconeAPIKey,
+			cfg.PineconeEnvironment,
+			cfg.PineconeIndexName,
+			cfg.PineconeHost,
+		)
+	case "memory":
+		log.Printf("Using in-memory vector store; indexed data will not survive a restart")
+		return storage.NewMemoryStore(), nil
+	case "local":
+		return storage.NewLocalStore(cfg.LocalStorePath, cfg.LocalStoreHNSW)
+	default:
+		return nil, fmt.Errorf("unknown vector store backend: %s", cfg.VectorStore)
+	}
+}
+
+func newEmbedder(cfg *config.Config, openaiClient *storage.OpenAIClient) (storage.Embedder, error) {
+	switch cfg.Embedder {
+	case "openai":
+		return openaiClient, nil
+	case "ollama":
+		return storage.NewOllamaEmbedder(cfg.OllamaURL, cfg.OllamaModel, cfg.OllamaMaxTokens), nil
+	case "openai-compatible":
+		return storage.NewOpenAICompatibleEmbedder(cfg.EmbeddingURL, cfg.EmbeddingAPIKey, cfg.EmbeddingModel, cfg.EmbeddingMaxTokens), nil
+	case "hashing":
+		log.Printf("Using hashing embedder; search results will not be semantic")
+		return storage.NewHashingEmbedder(cfg.EmbeddingDimensions), nil
+	default:
+		return nil, fmt.Errorf("unknown embedder: %s", cfg.Embedder)
+	}
+}
+
+// newCredentialStore opens the encrypted token store, or returns nil when no
+// key is configured, in which case only public repositories can be indexed
+func newCredentialStore(cfg *config.Config) (*storage.CredentialStore, error) {
+	if cfg.CredentialsKey == "" {
+		log.Printf("CREDENTIALS_KEY not set; storing GitHub tokens is disabled")
+		return nil, nil
+	}
+
+	key, err := storage.ParseCredentialKey(cfg.CredentialsKey)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewCredentialStore(cfg.CredentialsPath, key)
+}
+
 func setupRoutes(h *Handlers) *http.ServeMux {
 	mux := http.NewServeMux()
 
@@ -99,6 +250,7 @@ func setupRoutes(h *Handlers) *http.ServeMux {
 	mux.HandleFunc("/health", h.Health.HandleHealthCheck)
 
 	// MCP endpoints
+	mux.HandleFunc("/mcp", h.MCPProtocol.HandleMCP)
 	mux.HandleFunc("/mcp-info", h.MCP.HandleMCPRegistration)
 	mux.HandleFunc("/cursor", h.MCP.HandleCursorConnection)
 	mux.HandleFunc("/chat", h.MCP.HandleChat)
@@ -106,9 +258,50 @@ func setupRoutes(h *Handlers) *http.ServeMux {
 
 	// Vector search endpoints
 	mux.HandleFunc("/vector-search", h.VectorSearch.HandleVectorSearch)
+	mux.HandleFunc("/languages", h.VectorSearch.HandleLanguages)
 
 	// Repository indexing endpoints
 	mux.HandleFunc("/index-repository", h.RepoIndexer.HandleRepositoryIndexing)
+	mux.HandleFunc("/index-archive", h.RepoIndexer.HandleArchiveIndexing)
+	mux.HandleFunc("/index-status", h.RepoIndexer.HandleIndexStatus)
+	mux.HandleFunc("/index-cancel", h.RepoIndexer.HandleIndexCancel)
+	mux.HandleFunc("/index-purge", h.RepoIndexer.HandleIndexPurge)
+	mux.HandleFunc("/repositories", h.RepoIndexer.HandleRepositories)
 
 	return mux
-}
\ No newline at end of file
+}
+
+// setupMiddleware puts CORS and bearer authentication in front of every route
+// except the health check. MCP_SECRET_TOKEN is accepted as the "default"
+// credential alongside the named tokens in MCP_TOKENS.
+func setupMiddleware(cfg *config.Config, mux *http.ServeMux) (http.Handler, error) {
+	var router http.Handler = mux
+
+	if cfg.AuthDisabled {
+		log.Printf("Warning: authentication is disabled; every endpoint is open")
+	} else {
+		tokens, err := auth.ParseTokens(cfg.MCPTokens)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MCP_TOKENS: %w", err)
+		}
+		if cfg.MCPSecretToken != "" {
+			if _, exists := tokens["default"]; exists {
+				return nil, fmt.Errorf("MCP_TOKENS must not define \"default\" when MCP_SECRET_TOKEN is set")
+			}
+			tokens["default"] = cfg.MCPSecretToken
+		}
+
+		authenticator, err := auth.NewAuthenticator(tokens)
+		if err != nil {
+			return nil, err
+		}
+		if authenticator.Len() == 0 {
+			return nil, fmt.Errorf("no API tokens configured: set MCP_SECRET_TOKEN or MCP_TOKENS, or AUTH_DISABLED=true for local development")
+		}
+		log.Printf("Authentication enabled with %d token(s)", authenticator.Len())
+
+		router = handler.NewAuthMiddleware(authenticator, "/health").Wrap(router)
+	}
+
+	return handler.NewCORSMiddleware(cfg.CORSAllowedOrigins).Wrap(router), nil
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// legacyTokenHeader is the header the Node.js integration sends the secret in
+const legacyTokenHeader = "X-MCP-Token"
+
+// Principal identifies the credential a request was authenticated with
+type Principal struct {
+	Name string
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal a request was authenticated as,
+// if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+type namedToken struct {
+	name   string
+	digest [sha256.Size]byte
+}
+
+// Authenticator validates bearer tokens against a set of named credentials.
+// Several tokens can be live at once so one can be rotated out while its
+// replacement is rolled out to clients.
+type Authenticator struct {
+	tokens []namedToken
+}
+
+// NewAuthenticator builds an authenticator from a map of credential name to
+// token. Empty tokens are rejected so a blank variable cannot open access.
+func NewAuthenticator(tokens map[string]string) (*Authenticator, error) {
+	a := &Authenticator{}
+	for name, token := range tokens {
+		if token == "" {
+			return nil, fmt.Errorf("token %q is empty", name)
+		}
+		a.tokens = append(a.tokens, namedToken{name: name, digest: sha256.Sum256([]byte(token))})
+	}
+	return a, nil
+}
+
+// Len returns the number of configured tokens
+func (a *Authenticator) Len() int {
+	return len(a.tokens)
+}
+
+// Authenticate returns the principal owning token. Tokens are compared by
+// digest in constant time, and every credential is checked so the time
+// taken does not reveal which one matched.
+func (a *Authenticator) Authenticate(token string) (*Principal, bool) {
+	if token == "" {
+		return nil, false
+	}
+
+	digest := sha256.Sum256([]byte(token))
+	var match *namedToken
+	for i := range a.tokens {
+		if subtle.ConstantTimeCompare(digest[:], a.tokens[i].digest[:]) == 1 {
+			match = &a.tokens[i]
+		}
+	}
+	if match == nil {
+		return nil, false
+	}
+	return &Principal{Name: match.name}, true
+}
+
+// TokenFromRequest extracts the bearer token from the Authorization header,
+// falling back to the X-MCP-Token header used by older clients
+func TokenFromRequest(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return r.Header.Get(legacyTokenHeader)
+}
+
+// ParseTokens parses a comma-separated list of name=token pairs
+func ParseTokens(value string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for i, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, token, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			// Don't echo the entry, it may be a bare secret
+			return nil, fmt.Errorf("invalid token entry %d: expected name=token", i+1)
+		}
+		if _, exists := tokens[name]; exists {
+			return nil, fmt.Errorf("duplicate token name %q", name)
+		}
+		tokens[name] = strings.TrimSpace(token)
+	}
+	return tokens, nil
+}
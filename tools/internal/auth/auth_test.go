@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	a, err := NewAuthenticator(map[string]string{
+		"ci":      "ci-secret",
+		"laptop":  "laptop-secret",
+		"rotated": "next-secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", a.Len())
+	}
+
+	tests := []struct {
+		token string
+		want  string
+		ok    bool
+	}{
+		{"ci-secret", "ci", true},
+		{"laptop-secret", "laptop", true},
+		{"next-secret", "rotated", true},
+		{"", "", false},
+		{"ci-secret ", "", false},
+		{"CI-SECRET", "", false},
+		{"unknown", "", false},
+	}
+
+	for _, tt := range tests {
+		principal, ok := a.Authenticate(tt.token)
+		if ok != tt.ok {
+			t.Errorf("Authenticate(%q) ok = %v, want %v", tt.token, ok, tt.ok)
+			continue
+		}
+		if ok && principal.Name != tt.want {
+			t.Errorf("Authenticate(%q) = %q, want %q", tt.token, principal.Name, tt.want)
+		}
+	}
+}
+
+func TestNewAuthenticatorRejectsEmptyToken(t *testing.T) {
+	if _, err := NewAuthenticator(map[string]string{"ci": ""}); err == nil {
+		t.Fatal("NewAuthenticator() accepted an empty token")
+	}
+}
+
+func TestTokenFromRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"bearer", map[string]string{"Authorization": "Bearer abc"}, "abc"},
+		{"scheme is case-insensitive", map[string]string{"Authorization": "bearer  abc "}, "abc"},
+		{"other scheme", map[string]string{"Authorization": "Basic abc"}, ""},
+		{"scheme only", map[string]string{"Authorization": "Bearer"}, ""},
+		{"legacy header", map[string]string{"X-MCP-Token": "abc"}, "abc"},
+		{"authorization wins over legacy", map[string]string{"Authorization": "Basic abc", "X-MCP-Token": "legacy"}, ""},
+		{"none", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/mcp", nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			if got := TokenFromRequest(r); got != tt.want {
+				t.Errorf("TokenFromRequest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTokens(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"", map[string]string{}, false},
+		{"ci=abc", map[string]string{"ci": "abc"}, false},
+		{" ci = abc , laptop=a=b ,", map[string]string{"ci": "abc", "laptop": "a=b"}, false},
+		{"bare-secret", nil, true},
+		{"=abc", nil, true},
+		{"ci=abc,ci=def", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTokens(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTokens(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseTokens(%q) = %v, want %v", tt.value, got, tt.want)
+			continue
+		}
+		for name, token := range tt.want {
+			if got[name] != token {
+				t.Errorf("ParseTokens(%q)[%q] = %q, want %q", tt.value, name, got[name], token)
+			}
+		}
+	}
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	if _, ok := PrincipalFromContext(context.Background()); ok {
+		t.Error("PrincipalFromContext() found a principal in an empty context")
+	}
+
+	ctx := WithPrincipal(context.Background(), &Principal{Name: "ci"})
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.Name != "ci" {
+		t.Errorf("PrincipalFromContext() = %v, %v, want ci", principal, ok)
+	}
+}
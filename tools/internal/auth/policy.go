@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// ErrForbidden is returned when the caller may not access a repository
+var ErrForbidden = errors.New("access denied")
+
+// Action is an operation on a repository that the policy controls
+type Action string
+
+const (
+	ActionRead  Action = "read"
+	ActionIndex Action = "index"
+)
+
+// AnonymousPrincipal is the policy entry applied to requests that were not
+// authenticated, such as when authentication is disabled
+const AnonymousPrincipal = "anonymous"
+
+// anyPrincipal is the policy entry applied to every principal in addition to
+// its own
+const anyPrincipal = "*"
+
+// Grants lists the repositories a principal may read and index. Entries are
+// "owner/name" or "owner/name@branch" and may use path.Match wildcards, such
+// as "acme/*" or "acme/api@release-*"; a bare "*" matches anything. Index
+// access implies read access.
+type Grants struct {
+	Read  []string `json:"read"`
+	Index []string `json:"index"`
+}
+
+type repoPattern struct {
+	repository string
+	branch     string
+}
+
+func (p repoPattern) matches(repository, branch string) bool {
+	return matchPattern(p.repository, repository) && (p.branch == "" || matchPattern(p.branch, branch))
+}
+
+// matchPattern is path.Match, except that a bare "*" also matches across
+// slashes, as in owner/name or feature/x
+func matchPattern(pattern, value string) bool {
+	if pattern == "*" {
+		return true
+	}
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
+
+type principalGrants struct {
+	read  []repoPattern
+	index []repoPattern
+}
+
+// Policy maps principals to the repositories they may access. A nil Policy
+// allows everything, which keeps single-tenant deployments working without a
+// policy file.
+type Policy struct {
+	grants map[string]*principalGrants
+}
+
+// NewPolicy builds a policy from grants keyed by principal name
+func NewPolicy(grants map[string]Grants) (*Policy, error) {
+	p := &Policy{grants: make(map[string]*principalGrants, len(grants))}
+	for name, g := range grants {
+		compiled := &principalGrants{}
+		for _, entry := range g.Read {
+			pattern, err := parseRepoPattern(entry)
+			if err != nil {
+				return nil, fmt.Errorf("principal %q: %w", name, err)
+			}
+			compiled.read = append(compiled.read, pattern)
+		}
+		for _, entry := range g.Index {
+			pattern, err := parseRepoPattern(entry)
+			if err != nil {
+				return nil, fmt.Errorf("principal %q: %w", name, err)
+			}
+			compiled.index = append(compiled.index, pattern)
+		}
+		p.grants[name] = compiled
+	}
+	return p, nil
+}
+
+// LoadPolicy reads a JSON policy file mapping principal names to Grants. An
+// empty path returns a nil Policy, which allows everything.
+func LoadPolicy(filePath string) (*Policy, error) {
+	if filePath == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read access policy: %w", err)
+	}
+
+	var grants map[string]Grants
+	if err := json.Unmarshal(data, &grants); err != nil {
+		return nil, fmt.Errorf("failed to parse access policy %s: %w", filePath, err)
+	}
+	return NewPolicy(grants)
+}
+
+// Allowed reports whether the caller in ctx may perform action on the branch
+// of repository
+func (p *Policy) Allowed(ctx context.Context, action Action, repository, branch string) bool {
+	if p == nil {
+		return true
+	}
+
+	for _, name := range []string{principalName(ctx), anyPrincipal} {
+		g, ok := p.grants[name]
+		if !ok {
+			continue
+		}
+		if matchAny(g.index, repository, branch) {
+			return true
+		}
+		if action == ActionRead && matchAny(g.read, repository, branch) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize returns an error wrapping ErrForbidden when the caller in ctx may
+// not perform action on the branch of repository
+func (p *Policy) Authorize(ctx context.Context, action Action, repository, branch string) error {
+	if p.Allowed(ctx, action, repository, branch) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s may not %s %s@%s", ErrForbidden, principalName(ctx), action, repository, branch)
+}
+
+func principalName(ctx context.Context) string {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		return principal.Name
+	}
+	return AnonymousPrincipal
+}
+
+func parseRepoPattern(entry string) (repoPattern, error) {
+	repository, branch, _ := strings.Cut(strings.TrimSpace(entry), "@")
+	pattern := repoPattern{repository: repository, branch: branch}
+
+	if repository == "" {
+		return pattern, fmt.Errorf("invalid repository pattern %q", entry)
+	}
+	for _, part := range []string{repository, branch} {
+		if _, err := path.Match(part, ""); err != nil {
+			return pattern, fmt.Errorf("invalid repository pattern %q: %w", entry, err)
+		}
+	}
+	return pattern, nil
+}
+
+func matchAny(patterns []repoPattern, repository, branch string) bool {
+	for _, pattern := range patterns {
+		if pattern.matches(repository, branch) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicyAllowed(t *testing.T) {
+	policy, err := NewPolicy(map[string]Grants{
+		"ci": {
+			Index: []string{"acme/api@release-*", "acme/tools"},
+		},
+		"reader": {
+			Read: []string{"acme/*"},
+		},
+		"admin": {
+			Index: []string{"*"},
+		},
+		AnonymousPrincipal: {
+			Read: []string{"public/docs@main"},
+		},
+		anyPrincipal: {
+			Read: []string{"shared/*@*"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		principal  string
+		action     Action
+		repository string
+		branch     string
+		want       bool
+	}{
+		{"wildcard branch", "ci", ActionIndex, "acme/api", "release-1.2", true},
+		{"wildcard branch mismatch", "ci", ActionIndex, "acme/api", "main", false},
+		{"wildcard branch stops at slash", "ci", ActionIndex, "acme/api", "release-1/hotfix", false},
+		{"any branch", "ci", ActionIndex, "acme/tools", "feature/x", true},
+		{"index implies read", "ci", ActionRead, "acme/api", "release-2", true},
+		{"read only", "reader", ActionRead, "acme/web", "main", true},
+		{"read does not imply index", "reader", ActionIndex, "acme/web", "main", false},
+		{"owner wildcard stops at slash", "reader", ActionRead, "other/web", "main", false},
+		{"bare star repository", "admin", ActionIndex, "anyone/anything", "feature/deep/branch", true},
+		{"default principal", "", ActionRead, "public/docs", "main", true},
+		{"default principal other branch", "", ActionRead, "public/docs", "dev", false},
+		{"every principal", "ci", ActionRead, "shared/lib", "main", true},
+		{"every principal for anonymous", "", ActionRead, "shared/lib", "dev", true},
+		{"every principal read only", "ci", ActionIndex, "shared/lib", "main", false},
+		{"unknown principal", "stranger", ActionRead, "acme/web", "main", false},
+		{"unknown principal falls back to every principal", "stranger", ActionRead, "shared/lib", "main", true},
+		{"unknown principal is not anonymous", "stranger", ActionRead, "public/docs", "main", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != "" {
+				ctx = WithPrincipal(ctx, &Principal{Name: tt.principal})
+			}
+
+			if got := policy.Allowed(ctx, tt.action, tt.repository, tt.branch); got != tt.want {
+				t.Errorf("Allowed(%s, %s@%s) = %v, want %v", tt.action, tt.repository, tt.branch, got, tt.want)
+			}
+
+			err := policy.Authorize(ctx, tt.action, tt.repository, tt.branch)
+			if tt.want != (err == nil) {
+				t.Errorf("Authorize() error = %v", err)
+			}
+			if err != nil && !errors.Is(err, ErrForbidden) {
+				t.Errorf("Authorize() error = %v, want ErrForbidden", err)
+			}
+		})
+	}
+}
+
+func TestNilPolicyAllowsEverything(t *testing.T) {
+	var policy *Policy
+	ctx := WithPrincipal(context.Background(), &Principal{Name: "anyone"})
+	if err := policy.Authorize(ctx, ActionIndex, "acme/api", "main"); err != nil {
+		t.Errorf("Authorize() error = %v", err)
+	}
+}
+
+func TestNewPolicyRejectsInvalidPatterns(t *testing.T) {
+	for _, entry := range []string{"", "@main", "acme/[", "acme/api@[x"} {
+		if _, err := NewPolicy(map[string]Grants{"ci": {Read: []string{entry}}}); err == nil {
+			t.Errorf("NewPolicy(%q) succeeded", entry)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	policy, err := LoadPolicy("")
+	if err != nil || policy != nil {
+		t.Fatalf("LoadPolicy(\"\") = %v, %v, want nil policy", policy, err)
+	}
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "policy.json")
+	if err := os.WriteFile(filePath, []byte(`{"ci": {"index": ["acme/*"]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err = LoadPolicy(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !policy.Allowed(WithPrincipal(context.Background(), &Principal{Name: "ci"}), ActionIndex, "acme/api", "main") {
+		t.Error("loaded policy does not grant ci index access to acme/api")
+	}
+
+	if err := os.WriteFile(filePath, []byte(`{"ci": ["acme/*"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicy(filePath); err == nil {
+		t.Error("LoadPolicy() accepted a malformed policy")
+	}
+	if _, err := LoadPolicy(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("LoadPolicy() accepted a missing file")
+	}
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 )
 
 type Config struct {
@@ -12,6 +14,41 @@ type Config struct {
 	PineconeHost        string
 	OpenAIAPIKey        string
 	MCPSecretToken      string
+	MCPTokens           string
+	AuthDisabled        bool
+	CORSAllowedOrigins  []string
+	AccessPolicyPath    string
+	CredentialsPath     string
+	CredentialsKey      string
+	CredentialHosts     []string
+	VectorStore         string
+	LocalStorePath      string
+	LocalStoreHNSW      bool
+	Embedder            string
+	OllamaURL           string
+	OllamaModel         string
+	EmbeddingURL        string
+	EmbeddingModel      string
+	EmbeddingAPIKey     string
+	EmbeddingMaxTokens  int
+	EmbeddingDimensions int
+	EmbeddingBatchSize  int
+	UpsertBatchSize     int
+	IndexStatePath      string
+	CatalogPath         string
+	MCPSessionTimeout   int
+	IndexAllowedRoots   []string
+	MaxUploadMB         int
+	ArchiveMaxFiles     int
+	ArchiveMaxMB        int
+	IndexRulesPath      string
+	IndexExclude        []string
+	MaxFileSize         int
+	ChunkOverlapLines   int
+	ChunkSizing         string
+	ChunkMaxTokens      int
+	ChunkMaxBytes       int
+	OllamaMaxTokens     int
 }
 
 func Load() *Config {
@@ -23,6 +60,41 @@ func Load() *Config {
 		PineconeHost:        os.Getenv("PINECONE_HOST"),
 		OpenAIAPIKey:        os.Getenv("OPENAI_API_KEY"),
 		MCPSecretToken:      os.Getenv("MCP_SECRET_TOKEN"),
+		MCPTokens:           os.Getenv("MCP_TOKENS"),
+		AuthDisabled:        getEnvBool("AUTH_DISABLED", false),
+		CORSAllowedOrigins:  getEnvList("CORS_ALLOWED_ORIGINS"),
+		AccessPolicyPath:    os.Getenv("ACCESS_POLICY_PATH"),
+		CredentialsPath:     getEnv("CREDENTIALS_PATH", "data/credentials.json"),
+		CredentialsKey:      os.Getenv("CREDENTIALS_KEY"),
+		CredentialHosts:     getEnvList("GIT_CREDENTIAL_HOSTS"),
+		VectorStore:         getEnv("VECTOR_STORE", "pinecone"),
+		LocalStorePath:      getEnv("LOCAL_STORE_PATH", "data/vectors"),
+		LocalStoreHNSW:      getEnvBool("LOCAL_STORE_HNSW", false),
+		Embedder:            getEnv("EMBEDDER", "openai"),
+		OllamaURL:           getEnv("OLLAMA_URL", "http://localhost:11434"),
+		OllamaModel:         getEnv("OLLAMA_MODEL", "nomic-embed-text"),
+		EmbeddingURL:        getEnv("EMBEDDING_URL", "http://localhost:8080/v1"),
+		EmbeddingModel:      getEnv("EMBEDDING_MODEL", "nomic-embed-text"),
+		EmbeddingAPIKey:     os.Getenv("EMBEDDING_API_KEY"),
+		EmbeddingMaxTokens:  getEnvInt("EMBEDDING_MAX_TOKENS", 2048),
+		EmbeddingDimensions: getEnvInt("EMBEDDING_DIMENSIONS", 256),
+		EmbeddingBatchSize:  getEnvInt("EMBEDDING_BATCH_SIZE", 64),
+		UpsertBatchSize:     getEnvInt("UPSERT_BATCH_SIZE", 100),
+		IndexStatePath:      getEnv("INDEX_STATE_PATH", "data/index-state.json"),
+		CatalogPath:         getEnv("CATALOG_PATH", "data/catalog.json"),
+		MCPSessionTimeout:   getEnvInt("MCP_SESSION_TIMEOUT_MINUTES", 30),
+		IndexAllowedRoots:   getEnvList("INDEX_ALLOWED_ROOTS"),
+		MaxUploadMB:         getEnvInt("MAX_UPLOAD_MB", 100),
+		ArchiveMaxFiles:     getEnvInt("ARCHIVE_MAX_FILES", 50000),
+		ArchiveMaxMB:        getEnvInt("ARCHIVE_MAX_MB", 1024),
+		IndexRulesPath:      os.Getenv("INDEX_RULES_PATH"),
+		IndexExclude:        getEnvList("INDEX_EXCLUDE"),
+		MaxFileSize:         getEnvInt("MAX_FILE_SIZE", 100000),
+		ChunkOverlapLines:   getEnvInt("CHUNK_OVERLAP_LINES", 0),
+		ChunkSizing:         getEnv("CHUNK_SIZING", "bytes"),
+		ChunkMaxTokens:      getEnvInt("CHUNK_MAX_TOKENS", 512),
+		ChunkMaxBytes:       getEnvInt("CHUNK_MAX_BYTES", 1000),
+		OllamaMaxTokens:     getEnvInt("OLLAMA_MAX_TOKENS", 2048),
 	}
 }
 
@@ -31,4 +103,28 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -14,8 +14,6 @@ func NewHealthHandler() *HealthHandler {
 }
 
 func (h *HealthHandler) HandleHealthCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	
 	response := &models.APIResponse{
 		Success: true,
 		Data:    map[string]string{"status": "healthy"},
@@ -2,11 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
+	"mcpserver/internal/auth"
 	"mcpserver/internal/models"
 	"mcpserver/internal/service"
+	"mcpserver/internal/storage"
 )
 
 type MCPHandler struct {
@@ -20,31 +23,11 @@ func NewMCPHandler(service *service.MCPServerService) *MCPHandler {
 }
 
 func (h *MCPHandler) HandleMCPRegistration(w http.ResponseWriter, r *http.Request) {
-	// Enable CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
 	serverInfo := h.service.GetServerInfo()
 	sendMCPResponse(w, true, serverInfo, "")
 }
 
 func (h *MCPHandler) HandleCursorConnection(w http.ResponseWriter, r *http.Request) {
-	// Enable CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
 	var req models.CursorRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -53,7 +36,11 @@ func (h *MCPHandler) HandleCursorConnection(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Handle cursor action
-	result, err := h.service.HandleCursorAction(req.Action, req.Data)
+	result, err := h.service.HandleCursorAction(r.Context(), req.Action, req.Data)
+	if errors.Is(err, auth.ErrForbidden) {
+		sendResponseErrorStatus(w, http.StatusForbidden, err.Error())
+		return
+	}
 	if err != nil {
 		sendMCPResponse(w, false, nil, fmt.Sprintf("Cursor action failed: %v", err))
 		return
@@ -63,16 +50,6 @@ func (h *MCPHandler) HandleCursorConnection(w http.ResponseWriter, r *http.Reque
 }
 
 func (h *MCPHandler) HandleChat(w http.ResponseWriter, r *http.Request) {
-	// Enable CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
 	var req models.ChatRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -86,7 +63,11 @@ func (h *MCPHandler) HandleChat(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Handle chat
-	result, err := h.service.HandleChat(req.Message, req.Repository, req.Context)
+	result, err := h.service.HandleChat(r.Context(), req.Message, req.Repository, req.Context)
+	if errors.Is(err, auth.ErrForbidden) {
+		sendResponseErrorStatus(w, http.StatusForbidden, err.Error())
+		return
+	}
 	if err != nil {
 		sendMCPResponse(w, false, nil, fmt.Sprintf("Chat failed: %v", err))
 		return
@@ -95,14 +76,15 @@ func (h *MCPHandler) HandleChat(w http.ResponseWriter, r *http.Request) {
 	sendMCPResponse(w, true, result, "")
 }
 
+// HandleGitHubConfig stores a repository token on POST, lists the stored
+// tokens on GET and revokes one on DELETE with ?repository=
 func (h *MCPHandler) HandleGitHubConfig(w http.ResponseWriter, r *http.Request) {
-	// Enable CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
+	switch r.Method {
+	case http.MethodGet:
+		h.handleListGitHubCredentials(w, r)
+		return
+	case http.MethodDelete:
+		h.handleRevokeGitHub(w, r)
 		return
 	}
 
@@ -113,15 +95,15 @@ func (h *MCPHandler) HandleGitHubConfig(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if req.Repository == "" || req.Token == "" {
-		sendMCPResponse(w, false, nil, "Repository and token are required")
+	if req.Repository == "" || (req.Token == "" && req.SSHKey == "") {
+		sendMCPResponse(w, false, nil, "Repository and a token or SSH key are required")
 		return
 	}
 
 	// Configure GitHub
-	err := h.service.ConfigureGitHub(req.Repository, req.Token)
+	err := h.service.ConfigureGitHub(r.Context(), req.Repository, req.Token, req.SSHKey)
 	if err != nil {
-		sendMCPResponse(w, false, nil, fmt.Sprintf("GitHub configuration failed: %v", err))
+		sendResponseErrorStatus(w, credentialErrorStatus(err), fmt.Sprintf("GitHub configuration failed: %v", err))
 		return
 	}
 
@@ -129,6 +111,48 @@ func (h *MCPHandler) HandleGitHubConfig(w http.ResponseWriter, r *http.Request)
 	sendMCPResponse(w, true, result, "GitHub repository configured")
 }
 
+func (h *MCPHandler) handleListGitHubCredentials(w http.ResponseWriter, r *http.Request) {
+	credentials, err := h.service.ListGitHubCredentials(r.Context())
+	if err != nil {
+		sendResponseErrorStatus(w, credentialErrorStatus(err), err.Error())
+		return
+	}
+
+	sendMCPResponse(w, true, credentials, "")
+}
+
+func (h *MCPHandler) handleRevokeGitHub(w http.ResponseWriter, r *http.Request) {
+	repository := r.URL.Query().Get("repository")
+	if repository == "" {
+		sendResponseErrorStatus(w, http.StatusBadRequest, "Repository is required")
+		return
+	}
+
+	if err := h.service.RevokeGitHub(r.Context(), repository); err != nil {
+		sendResponseErrorStatus(w, credentialErrorStatus(err), fmt.Sprintf("GitHub revocation failed: %v", err))
+		return
+	}
+
+	result := map[string]string{"status": "revoked"}
+	sendMCPResponse(w, true, result, "GitHub credentials revoked")
+}
+
+// credentialErrorStatus maps credential store errors to HTTP statuses
+func credentialErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, auth.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, storage.ErrCredentialNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidCredential):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrCredentialsDisabled):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func sendMCPResponse(w http.ResponseWriter, success bool, data interface{}, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	response := &models.APIResponse{
@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"mcpserver/internal/auth"
+	"mcpserver/internal/mcp"
+)
+
+// maxMCPMessageSize bounds the size of a single JSON-RPC message or batch
+const maxMCPMessageSize = 4 << 20
+
+// mcpKeepAliveInterval is how often idle SSE streams get a comment line so
+// proxies do not close them
+const mcpKeepAliveInterval = 25 * time.Second
+
+// MCPProtocolHandler implements the MCP Streamable HTTP transport. Clients
+// POST JSON-RPC messages and get the reply either as JSON or, when they
+// accept it, as a server-sent event stream that also carries progress
+// notifications. A GET opens a standalone stream for server-initiated
+// messages and a DELETE ends the session.
+type MCPProtocolHandler struct {
+	server   *mcp.Server
+	sessions *mcp.SessionStore
+}
+
+func NewMCPProtocolHandler(server *mcp.Server, sessions *mcp.SessionStore) *MCPProtocolHandler {
+	return &MCPProtocolHandler{
+		server:   server,
+		sessions: sessions,
+	}
+}
+
+func (h *MCPProtocolHandler) HandleMCP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleStream(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *MCPProtocolHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMCPMessageSize))
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	hasRequests, isInitialize := inspectMessages(body)
+
+	// initialize opens a new session; every other message must belong to one
+	var session *mcp.Session
+	if isInitialize {
+		session, err = h.sessions.Create(requestPrincipal(r))
+		if err != nil {
+			http.Error(w, "Failed to create session", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(mcp.SessionHeader, session.ID)
+	} else {
+		var status int
+		session, status = h.lookupSession(r)
+		if session == nil {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+	}
+
+	// Notifications and responses are acknowledged without a body
+	if !hasRequests {
+		h.server.HandleMessage(r.Context(), body)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if !acceptsEventStream(r) {
+		// Without an SSE response stream, notifications for this request go
+		// to the session's standalone stream instead
+		ctx := mcp.WithNotifier(r.Context(), func(notification *mcp.Notification) {
+			if data, err := json.Marshal(notification); err == nil {
+				session.Send(data)
+			}
+		})
+
+		response := h.server.HandleMessage(ctx, body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+		return
+	}
+
+	stream, ok := newEventStream(w)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx := mcp.WithNotifier(r.Context(), func(notification *mcp.Notification) {
+		if data, err := json.Marshal(notification); err == nil {
+			stream.send(data)
+		}
+	})
+
+	if response := h.server.HandleMessage(ctx, body); response != nil {
+		stream.send(response)
+	}
+}
+
+// handleStream serves the standalone SSE stream of a session
+func (h *MCPProtocolHandler) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !acceptsEventStream(r) {
+		http.Error(w, "Client must accept text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	session, status := h.lookupSession(r)
+	if session == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	stream, ok := newEventStream(w)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("MCP session %s opened event stream", session.ID)
+
+	ticker := time.NewTicker(mcpKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case message, ok := <-session.Messages():
+			if !ok {
+				return
+			}
+			stream.send(message)
+		case <-ticker.C:
+			// An open stream keeps the session alive
+			session.Touch()
+			stream.comment("keep-alive")
+		}
+	}
+}
+
+func (h *MCPProtocolHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	session, status := h.lookupSession(r)
+	if session == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	if !h.sessions.Delete(session.ID) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("MCP session %s terminated by client", session.ID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// lookupSession resolves the request's session, returning the HTTP status to
+// reply with when there is none: 400 without a session ID and 404 for an
+// unknown or expired one, which tells the client to initialize again. A
+// session opened by another principal is reported as unknown, so its ID
+// reveals nothing.
+func (h *MCPProtocolHandler) lookupSession(r *http.Request) (*mcp.Session, int) {
+	id := r.Header.Get(mcp.SessionHeader)
+	if id == "" {
+		return nil, http.StatusBadRequest
+	}
+
+	session, ok := h.sessions.Get(id)
+	if !ok || session.Principal != requestPrincipal(r) {
+		return nil, http.StatusNotFound
+	}
+	return session, 0
+}
+
+// requestPrincipal names the credential a request was authenticated with
+func requestPrincipal(r *http.Request) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return principal.Name
+	}
+	return auth.AnonymousPrincipal
+}
+
+// inspectMessages reports whether a POST body holds any requests (as opposed
+// to only notifications and responses) and whether it is an initialize
+// request
+func inspectMessages(body []byte) (hasRequests, isInitialize bool) {
+	var messages []mcp.Request
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		if err := json.Unmarshal(body, &messages); err != nil {
+			return true, false
+		}
+	} else {
+		var message mcp.Request
+		if err := json.Unmarshal(body, &message); err != nil {
+			// Let the server produce the parse error response
+			return true, false
+		}
+		messages = []mcp.Request{message}
+	}
+
+	for _, message := range messages {
+		if message.Method != "" && !message.IsNotification() {
+			hasRequests = true
+			if message.Method == "initialize" {
+				isInitialize = true
+			}
+		}
+	}
+	return hasRequests, isInitialize
+}
+
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// eventStream writes server-sent events, flushing after each one
+type eventStream struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newEventStream(w http.ResponseWriter) (*eventStream, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &eventStream{w: w, flusher: flusher}, true
+}
+
+func (s *eventStream) send(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintf(s.w, "event: message\ndata: %s\n\n", data)
+	s.flusher.Flush()
+}
+
+func (s *eventStream) comment(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintf(s.w, ": %s\n\n", text)
+	s.flusher.Flush()
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mcpserver/internal/auth"
+	"mcpserver/internal/mcp"
+)
+
+func TestMCPSessionsBelongToTheirPrincipal(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(map[string]string{"alice": "alice-token", "bob": "bob-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := mcp.NewServer(mcp.Implementation{Name: "test", Version: "1"})
+	h := NewAuthMiddleware(authenticator).Wrap(http.HandlerFunc(NewMCPProtocolHandler(server, mcp.NewSessionStore(0)).HandleMCP))
+
+	send := func(method, token, sessionID, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(method, "/mcp", strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer "+token)
+		r.Header.Set("Accept", "application/json")
+		if sessionID != "" {
+			r.Header.Set(mcp.SessionHeader, sessionID)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := send(http.MethodPost, "alice-token", "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+	sessionID := w.Header().Get(mcp.SessionHeader)
+	if w.Code != http.StatusOK || sessionID == "" {
+		t.Fatalf("initialize = %d with session %q", w.Code, sessionID)
+	}
+
+	const list = `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		body   string
+		want   int
+	}{
+		{"other principal posts", http.MethodPost, "bob-token", list, http.StatusNotFound},
+		{"other principal deletes", http.MethodDelete, "bob-token", "", http.StatusNotFound},
+		{"owner posts", http.MethodPost, "alice-token", list, http.StatusOK},
+		{"owner deletes", http.MethodDelete, "alice-token", "", http.StatusOK},
+		{"deleted session", http.MethodPost, "alice-token", list, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		if w := send(tt.method, tt.token, sessionID, tt.body); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestMCPStreamRejectsOtherPrincipal(t *testing.T) {
+	sessions := mcp.NewSessionStore(0)
+	session, err := sessions.Create("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewMCPProtocolHandler(mcp.NewServer(mcp.Implementation{Name: "test", Version: "1"}), sessions)
+
+	r := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	r = r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{Name: "bob"}))
+	r.Header.Set("Accept", "text/event-stream")
+	r.Header.Set(mcp.SessionHeader, session.ID)
+	w := httptest.NewRecorder()
+	h.HandleMCP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
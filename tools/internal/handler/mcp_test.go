@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcpserver/internal/auth"
+	"mcpserver/internal/service"
+	"mcpserver/internal/storage"
+)
+
+func TestHandleGitHubConfigStatus(t *testing.T) {
+	credentials, err := storage.NewCredentialStore(filepath.Join(t.TempDir(), "credentials.json"), bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := auth.NewPolicy(map[string]auth.Grants{"ci": {Index: []string{"acme/*"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewMCPHandler(service.NewMCPServerService(nil, nil, credentials, policy))
+	disabled := NewMCPHandler(service.NewMCPServerService(nil, nil, nil, nil))
+
+	tests := []struct {
+		name    string
+		handler *MCPHandler
+		body    string
+		want    int
+	}{
+		{"token", h, `{"repository": "acme/api", "token": "ghp_secret"}`, http.StatusOK},
+		{"blank token", h, `{"repository": "acme/api", "token": "   "}`, http.StatusBadRequest},
+		{"SSH key not PEM", h, `{"repository": "acme/api", "sshKey": "ssh-ed25519 AAAA"}`, http.StatusBadRequest},
+		{"forbidden repository", h, `{"repository": "other/api", "token": "ghp_secret"}`, http.StatusForbidden},
+		{"storage disabled", disabled, `{"repository": "acme/api", "token": "ghp_secret"}`, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/github/config", strings.NewReader(tt.body))
+			r = r.WithContext(auth.WithPrincipal(context.Background(), &auth.Principal{Name: "ci"}))
+			w := httptest.NewRecorder()
+			tt.handler.HandleGitHubConfig(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
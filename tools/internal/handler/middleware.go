@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"mcpserver/internal/auth"
+	"mcpserver/internal/mcp"
+)
+
+// corsAllowedMethods and corsAllowedHeaders cover every route the server
+// exposes
+const (
+	corsAllowedMethods = "GET, POST, DELETE, OPTIONS"
+	corsAllowedHeaders = "Content-Type, Authorization, Accept, X-MCP-Token, " + mcp.SessionHeader
+)
+
+// AuthMiddleware rejects requests without a valid bearer token. Paths listed
+// as public, such as the health check, are served without one.
+type AuthMiddleware struct {
+	authenticator *auth.Authenticator
+	public        map[string]bool
+}
+
+func NewAuthMiddleware(authenticator *auth.Authenticator, publicPaths ...string) *AuthMiddleware {
+	public := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		public[path] = true
+	}
+
+	return &AuthMiddleware{
+		authenticator: authenticator,
+		public:        public,
+	}
+}
+
+func (m *AuthMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Preflight requests never carry credentials
+		if m.public[r.URL.Path] || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, ok := m.authenticator.Authenticate(auth.TokenFromRequest(r))
+		if !ok {
+			log.Printf("Rejected unauthenticated %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+			sendResponseErrorStatus(w, http.StatusUnauthorized, "Missing or invalid bearer token")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// CORSMiddleware answers preflight requests and only grants cross-origin
+// access to the configured origins. A "*" entry allows any origin.
+type CORSMiddleware struct {
+	allowAll bool
+	origins  map[string]bool
+}
+
+func NewCORSMiddleware(allowedOrigins []string) *CORSMiddleware {
+	m := &CORSMiddleware{origins: make(map[string]bool)}
+	for _, origin := range allowedOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		switch origin {
+		case "":
+		case "*":
+			m.allowAll = true
+		default:
+			m.origins[origin] = true
+		}
+	}
+	return m
+}
+
+func (m *CORSMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := origin != "" && (m.allowAll || m.origins[origin])
+
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			w.Header().Set("Access-Control-Expose-Headers", mcp.SessionHeader)
+		}
+		w.Header().Add("Vary", "Origin")
+
+		if r.Method == http.MethodOptions {
+			if !allowed {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mcpserver/internal/auth"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(map[string]string{"ci": "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var principal string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := auth.PrincipalFromContext(r.Context()); ok {
+			principal = p.Name
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := NewAuthMiddleware(authenticator, "/health").Wrap(next)
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		headers       map[string]string
+		wantStatus    int
+		wantPrincipal string
+	}{
+		{"bearer token", http.MethodPost, "/mcp", map[string]string{"Authorization": "Bearer secret"}, http.StatusOK, "ci"},
+		{"legacy header", http.MethodPost, "/mcp", map[string]string{"X-MCP-Token": "secret"}, http.StatusOK, "ci"},
+		{"wrong token", http.MethodPost, "/mcp", map[string]string{"Authorization": "Bearer wrong"}, http.StatusUnauthorized, ""},
+		{"no token", http.MethodPost, "/mcp", nil, http.StatusUnauthorized, ""},
+		{"health is public", http.MethodGet, "/health", nil, http.StatusOK, ""},
+		{"health prefix is not public", http.MethodGet, "/health/../mcp", nil, http.StatusUnauthorized, ""},
+		{"preflight", http.MethodOptions, "/mcp", nil, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = ""
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 response has no WWW-Authenticate header")
+			}
+			if principal != tt.wantPrincipal {
+				t.Errorf("principal = %q, want %q", principal, tt.wantPrincipal)
+			}
+		})
+	}
+}
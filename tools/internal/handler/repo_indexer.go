@@ -2,34 +2,33 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"strconv"
 
+	"mcpserver/internal/auth"
 	"mcpserver/internal/models"
 	"mcpserver/internal/service"
+	"mcpserver/pkg/git"
 )
 
 type RepoIndexerHandler struct {
 	service *service.RepoIndexerService
+	// maxUploadBytes caps the size of an uploaded archive
+	maxUploadBytes int64
 }
 
-func NewRepoIndexerHandler(service *service.RepoIndexerService) *RepoIndexerHandler {
+func NewRepoIndexerHandler(service *service.RepoIndexerService, maxUploadBytes int64) *RepoIndexerHandler {
 	return &RepoIndexerHandler{
-		service: service,
+		service:        service,
+		maxUploadBytes: maxUploadBytes,
 	}
 }
 
 func (h *RepoIndexerHandler) HandleRepositoryIndexing(w http.ResponseWriter, r *http.Request) {
-	// Enable CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
 	var req models.IndexRepositoryRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -37,8 +36,16 @@ func (h *RepoIndexerHandler) HandleRepositoryIndexing(w http.ResponseWriter, r *
 		return
 	}
 
-	if req.RepoURL == "" {
-		sendResponseError(w, "Repository URL is required")
+	if req.RepoURL == "" && req.Path == "" {
+		sendResponseError(w, "Repository URL or path is required")
+		return
+	}
+	if req.RepoURL != "" && req.Path != "" {
+		sendResponseError(w, "Only one of repository URL and path may be given")
+		return
+	}
+	if req.Path != "" && req.Repository == "" {
+		sendResponseError(w, "Repository name is required when indexing a path")
 		return
 	}
 
@@ -47,19 +54,248 @@ func (h *RepoIndexerHandler) HandleRepositoryIndexing(w http.ResponseWriter, r *
 		req.Branch = "main"
 	}
 
-	// Index repository
-	err := h.service.IndexRepository(req.RepoURL, req.Branch)
+	// Index repository in the background
+	var job *models.IndexJob
+	var err error
+	if req.Path != "" {
+		job, err = h.service.StartPathIndexJob(r.Context(), req.Path, req.Repository, req.Branch, req.IndexFilter)
+	} else {
+		job, err = h.service.StartIndexJob(r.Context(), req.RepoURL, req.Branch, req.IndexFilter)
+	}
+	if errors.Is(err, auth.ErrForbidden) || errors.Is(err, service.ErrPathNotAllowed) || errors.Is(err, service.ErrLocalPathsDisabled) {
+		sendResponseErrorStatus(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrInvalidRepository) || errors.Is(err, service.ErrInvalidBranch) || errors.Is(err, git.ErrInvalidRemote) || errors.Is(err, git.ErrInvalidRef) {
+		sendResponseErrorStatus(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		sendResponseError(w, fmt.Sprintf("Repository indexing failed: %v", err))
 		return
 	}
 
 	result := map[string]interface{}{
-		"status":  "success",
-		"message": "Repository indexed successfully",
+		"jobId":  job.ID,
+		"status": job.Status,
+	}
+
+	sendResponseSuccess(w, result, "Repository indexing started")
+}
+
+// HandleArchiveIndexing indexes an uploaded tar.gz or zip archive. It takes
+// a multipart form with the archive in the "archive" field, the "repository"
+// and optional "branch" to index it as, and optional repeated "include" and
+// "exclude" patterns and a "maxFileSize".
+func (h *RepoIndexerHandler) HandleArchiveIndexing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendResponseErrorStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadBytes)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			sendResponseErrorStatus(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Archive is larger than %d bytes", h.maxUploadBytes))
+			return
+		}
+		sendResponseError(w, "Invalid request format")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	repository := r.FormValue("repository")
+	if repository == "" {
+		sendResponseError(w, "Repository name is required")
+		return
+	}
+
+	// Set default branch if not provided
+	branch := r.FormValue("branch")
+	if branch == "" {
+		branch = "main"
+	}
+
+	filter := models.IndexFilter{
+		Include: r.MultipartForm.Value["include"],
+		Exclude: r.MultipartForm.Value["exclude"],
+	}
+	if value := r.FormValue("maxFileSize"); value != "" {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			sendResponseError(w, "Invalid maxFileSize")
+			return
+		}
+		filter.MaxFileSize = size
+	}
+
+	file, _, err := r.FormFile("archive")
+	if err != nil {
+		sendResponseError(w, "Archive file is required")
+		return
+	}
+	defer file.Close()
+
+	// The job outlives the request, so the upload is copied somewhere it
+	// owns
+	archivePath, err := saveUpload(file)
+	if err != nil {
+		sendResponseErrorStatus(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	job, err := h.service.StartArchiveIndexJob(r.Context(), archivePath, repository, branch, filter)
+	if errors.Is(err, auth.ErrForbidden) {
+		sendResponseErrorStatus(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrInvalidRepository) || errors.Is(err, service.ErrInvalidBranch) {
+		sendResponseErrorStatus(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		sendResponseError(w, fmt.Sprintf("Archive indexing failed: %v", err))
+		return
+	}
+
+	result := map[string]interface{}{
+		"jobId":  job.ID,
+		"status": job.Status,
+	}
+
+	sendResponseSuccess(w, result, "Archive indexing started")
+}
+
+// saveUpload copies an uploaded file to a new temporary file and returns its
+// path
+func saveUpload(r io.Reader) (string, error) {
+	f, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to store upload: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to store upload: %w", err)
+	}
+	return f.Name(), nil
+}
+
+func (h *RepoIndexerHandler) HandleIndexStatus(w http.ResponseWriter, r *http.Request) {
+	// Without a job ID, report every known job
+	jobID := r.URL.Query().Get("jobId")
+	if jobID == "" {
+		sendResponseSuccess(w, h.service.ListIndexJobs(r.Context()), "")
+		return
+	}
+
+	job, err := h.service.GetIndexJob(r.Context(), jobID)
+	if errors.Is(err, auth.ErrForbidden) {
+		sendResponseErrorStatus(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		sendResponseErrorStatus(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	sendResponseSuccess(w, job, "")
+}
+
+func (h *RepoIndexerHandler) HandleIndexCancel(w http.ResponseWriter, r *http.Request) {
+	var req models.IndexJobRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendResponseError(w, "Invalid request format")
+		return
+	}
+
+	if req.JobID == "" {
+		sendResponseError(w, "Job ID is required")
+		return
+	}
+
+	job, err := h.service.CancelIndexJob(r.Context(), req.JobID)
+	if errors.Is(err, auth.ErrForbidden) {
+		sendResponseErrorStatus(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrJobNotFound) {
+		sendResponseErrorStatus(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		sendResponseErrorStatus(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	sendResponseSuccess(w, job, "Cancellation requested")
+}
+
+// HandleRepositories lists the indexed repositories and branches the caller
+// may read. With ?repository= it lists only that repository.
+func (h *RepoIndexerHandler) HandleRepositories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponseErrorStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	repositories := h.service.ListRepositories(r.Context())
+
+	name := r.URL.Query().Get("repository")
+	if name == "" {
+		sendResponseSuccess(w, repositories, "")
+		return
+	}
+	for _, repo := range repositories {
+		if repo.Repository == name {
+			sendResponseSuccess(w, repo, "")
+			return
+		}
+	}
+	sendResponseErrorStatus(w, http.StatusNotFound, fmt.Sprintf("Repository %s is not indexed", name))
+}
+
+// HandleIndexPurge removes a repository, one of its branches, or files of a
+// branch from the index
+func (h *RepoIndexerHandler) HandleIndexPurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendResponseErrorStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req models.PurgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendResponseError(w, "Invalid request format")
+		return
+	}
+
+	if req.Repository == "" {
+		sendResponseError(w, "Repository is required")
+		return
+	}
+
+	result, err := h.service.PurgeIndex(r.Context(), req)
+	if errors.Is(err, auth.ErrForbidden) {
+		sendResponseErrorStatus(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrAlreadyIndexing) {
+		sendResponseErrorStatus(w, http.StatusConflict, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrInvalidRepository) || errors.Is(err, service.ErrPurgePathsNeedBranch) || errors.Is(err, service.ErrInvalidPurgePath) {
+		sendResponseErrorStatus(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		sendResponseError(w, fmt.Sprintf("Purge failed: %v", err))
+		return
 	}
 
-	sendResponseSuccess(w, result, "Repository indexed successfully")
+	sendResponseSuccess(w, result, fmt.Sprintf("Deleted %d vectors", result.Deleted))
 }
 
 func sendResponseSuccess(w http.ResponseWriter, data interface{}, message string) {
@@ -80,4 +316,15 @@ func sendResponseError(w http.ResponseWriter, message string) {
 		Message: message,
 	}
 	json.NewEncoder(w).Encode(response)
-}
\ No newline at end of file
+}
+
+func sendResponseErrorStatus(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	response := &models.APIResponse{
+		Success: false,
+		Data:    nil,
+		Message: message,
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mcpserver/internal/service"
+	"mcpserver/internal/storage"
+)
+
+func TestHandleRepositoryIndexingInvalidInput(t *testing.T) {
+	root := t.TempDir()
+	indexer := service.NewRepoIndexerService(storage.NewMemoryStore(), storage.NewHashingEmbedder(8), nil, nil, nil, nil, service.IndexerOptions{AllowedRoots: []string{root}})
+	h := NewRepoIndexerHandler(indexer, 1<<20)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"path with ':' branch", `{"path": "` + root + `", "repository": "acme/api", "branch": "a:b"}`},
+		{"path with invalid repository", `{"path": "` + root + `", "repository": "acme@api"}`},
+		{"option as repo URL", `{"repoUrl": "--upload-pack=touch /tmp/pwned"}`},
+		{"option as branch", `{"repoUrl": "https://github.com/acme/api.git", "branch": "--upload-pack=x"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.HandleRepositoryIndexing(w, httptest.NewRequest(http.MethodPost, "/index-repository", strings.NewReader(tt.body)))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400: %s", w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -2,11 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
+	"mcpserver/internal/auth"
 	"mcpserver/internal/models"
 	"mcpserver/internal/service"
+	"mcpserver/pkg/language"
 )
 
 type VectorSearchHandler struct {
@@ -20,20 +23,13 @@ func NewVectorSearchHandler(service *service.VectorSearchService) *VectorSearchH
 }
 
 func (h *VectorSearchHandler) HandleVectorSearch(w http.ResponseWriter, r *http.Request) {
-	// Enable CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
-	}
-
 	var req struct {
-		Query      string `json:"query"`
-		Repository string `json:"repository"`
-		Branch     string `json:"branch"`
+		Query      string   `json:"query"`
+		Repository string   `json:"repository"`
+		Branch     string   `json:"branch"`
+		Languages  []string `json:"languages"`
+
+		Documentation models.DocumentationMode `json:"documentation"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -57,10 +53,21 @@ func (h *VectorSearchHandler) HandleVectorSearch(w http.ResponseWriter, r *http.
 		Repository: req.Repository,
 		Branch:     req.Branch,
 		Limit:      10,
+		Languages:  req.Languages,
+
+		Documentation: req.Documentation,
 	}
 
 	// Execute vector search with summary
-	result, err := h.service.SearchWithSummary(searchRequest)
+	result, err := h.service.SearchWithSummary(r.Context(), searchRequest)
+	if errors.Is(err, auth.ErrForbidden) {
+		sendResponseErrorStatus(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrUnknownLanguage) || errors.Is(err, service.ErrInvalidDocumentationMode) {
+		sendResponseErrorStatus(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		sendResponse(w, false, nil, fmt.Sprintf("Search failed: %v", err))
 		return
@@ -69,6 +76,17 @@ func (h *VectorSearchHandler) HandleVectorSearch(w http.ResponseWriter, r *http.
 	sendResponse(w, true, result, "")
 }
 
+// HandleLanguages lists the languages files are detected as, which searches
+// can filter by
+func (h *VectorSearchHandler) HandleLanguages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponseErrorStatus(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	sendResponse(w, true, language.All(), "")
+}
+
 func sendResponse(w http.ResponseWriter, success bool, data interface{}, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	response := &models.APIResponse{
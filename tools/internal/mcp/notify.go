@@ -0,0 +1,75 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type notifierKey struct{}
+
+type progressKey struct{}
+
+// Notifier delivers a server-to-client notification over the transport the
+// current request arrived on
+type Notifier func(notification *Notification)
+
+// ProgressFunc reports progress of a long-running request. Progress must
+// increase with every call; total is omitted when zero.
+type ProgressFunc func(progress, total float64, message string)
+
+// ProgressParams is the payload of notifications/progress
+type ProgressParams struct {
+	ProgressToken json.RawMessage `json:"progressToken"`
+	Progress      float64         `json:"progress"`
+	Total         float64         `json:"total,omitempty"`
+	Message       string          `json:"message,omitempty"`
+}
+
+// requestMeta is the _meta field clients may attach to request params
+type requestMeta struct {
+	Meta struct {
+		ProgressToken json.RawMessage `json:"progressToken"`
+	} `json:"_meta"`
+}
+
+// WithNotifier returns a context whose requests can send notifications back
+// to the client through notify
+func WithNotifier(ctx context.Context, notify Notifier) context.Context {
+	return context.WithValue(ctx, notifierKey{}, notify)
+}
+
+// ReportProgress sends a progress notification for the current request if
+// the client asked for progress and the transport can deliver it
+func ReportProgress(ctx context.Context, progress, total float64, message string) {
+	if report, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		report(progress, total, message)
+	}
+}
+
+// withRequestProgress wires ReportProgress to notifications/progress when the
+// request carries a progress token
+func withRequestProgress(ctx context.Context, params json.RawMessage) context.Context {
+	notify, ok := ctx.Value(notifierKey{}).(Notifier)
+	if !ok || len(params) == 0 {
+		return ctx
+	}
+
+	var meta requestMeta
+	if err := json.Unmarshal(params, &meta); err != nil || len(meta.Meta.ProgressToken) == 0 {
+		return ctx
+	}
+
+	token := meta.Meta.ProgressToken
+	return context.WithValue(ctx, progressKey{}, ProgressFunc(func(progress, total float64, message string) {
+		notify(&Notification{
+			JSONRPC: "2.0",
+			Method:  "notifications/progress",
+			Params: &ProgressParams{
+				ProgressToken: token,
+				Progress:      progress,
+				Total:         total,
+				Message:       message,
+			},
+		})
+	}))
+}
@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"mcpserver/internal/service"
+)
+
+// ResourceFunc returns the current text of a resource
+type ResourceFunc func(ctx context.Context) (string, error)
+
+type resourceHandler struct {
+	resource Resource
+	read     ResourceFunc
+}
+
+// RegisterResource exposes a resource to clients, replacing any resource
+// with the same URI
+func (s *Server) RegisterResource(resource Resource, read ResourceFunc) {
+	s.resources[resource.URI] = &resourceHandler{resource: resource, read: read}
+}
+
+func (s *Server) listResources() *ListResourcesResult {
+	resources := make([]Resource, 0, len(s.resources))
+	for _, handler := range s.resources {
+		resources = append(resources, handler.resource)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].URI < resources[j].URI
+	})
+	return &ListResourcesResult{Resources: resources}
+}
+
+func (s *Server) readResource(ctx context.Context, params json.RawMessage) (interface{}, *Error) {
+	var p ReadResourceParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, newError(CodeInvalidParams, "Invalid resources/read params")
+	}
+
+	handler, ok := s.resources[p.URI]
+	if !ok {
+		return nil, newError(CodeResourceNotFound, fmt.Sprintf("Resource not found: %s", p.URI))
+	}
+
+	text, err := handler.read(ctx)
+	if err != nil {
+		return nil, newError(CodeInternalError, err.Error())
+	}
+	return &ReadResourceResult{Contents: []ResourceContents{{
+		URI:      p.URI,
+		MimeType: handler.resource.MimeType,
+		Text:     text,
+	}}}, nil
+}
+
+// CatalogResourceURI is the resource listing the indexed repositories
+const CatalogResourceURI = "catalog://repositories"
+
+// RegisterServiceResources exposes the catalog of indexed repositories as a
+// resource
+func RegisterServiceResources(s *Server, repoIndexer *service.RepoIndexerService) {
+	s.RegisterResource(Resource{
+		URI:         CatalogResourceURI,
+		Name:        "Indexed repositories",
+		Description: "Repositories and branches that can be searched, with the commit, file and chunk counts, languages, embedding model and time of their last index.",
+		MimeType:    "application/json",
+	}, func(ctx context.Context) (string, error) {
+		data, err := json.MarshalIndent(repoIndexer.ListRepositories(ctx), "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	})
+}
@@ -0,0 +1,204 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// Server implements the MCP protocol on top of JSON-RPC 2.0. It is transport
+// agnostic: transports feed it raw messages and write back what it returns.
+type Server struct {
+	info      Implementation
+	tools     map[string]*toolHandler
+	resources map[string]*resourceHandler
+}
+
+func NewServer(info Implementation) *Server {
+	return &Server{
+		info:      info,
+		tools:     make(map[string]*toolHandler),
+		resources: make(map[string]*resourceHandler),
+	}
+}
+
+// HandleMessage processes a single JSON-RPC message or batch and returns the
+// encoded response. It returns nil when nothing needs to be sent back, as for
+// notifications.
+func (s *Server) HandleMessage(ctx context.Context, data []byte) []byte {
+	data = bytes.TrimSpace(data)
+
+	// Batches are arrays of requests answered by an array of responses
+	if len(data) > 0 && data[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return encode(errorResponse(nil, newError(CodeParseError, "Parse error")))
+		}
+		if len(batch) == 0 {
+			return encode(errorResponse(nil, newError(CodeInvalidRequest, "Empty batch")))
+		}
+
+		var responses []*Response
+		for _, message := range batch {
+			if resp := s.handleRaw(ctx, message); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		return encode(responses)
+	}
+
+	if resp := s.handleRaw(ctx, data); resp != nil {
+		return encode(resp)
+	}
+	return nil
+}
+
+func (s *Server) handleRaw(ctx context.Context, data []byte) *Response {
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return errorResponse(nil, newError(CodeParseError, "Parse error"))
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, newError(CodeInvalidRequest, "Invalid request"))
+	}
+	return s.Handle(ctx, &req)
+}
+
+// Handle dispatches a decoded request and returns its response, or nil for
+// notifications
+func (s *Server) Handle(ctx context.Context, req *Request) *Response {
+	result, rpcErr := s.dispatch(ctx, req)
+
+	if req.IsNotification() {
+		if rpcErr != nil {
+			log.Printf("MCP notification %s failed: %v", req.Method, rpcErr)
+		}
+		return nil
+	}
+
+	if rpcErr != nil {
+		return errorResponse(req.ID, rpcErr)
+	}
+	return &Response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func (s *Server) dispatch(ctx context.Context, req *Request) (interface{}, *Error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return s.listTools(), nil
+	case "tools/call":
+		return s.callTool(withRequestProgress(ctx, req.Params), req.Params)
+	case "resources/list":
+		return s.listResources(), nil
+	case "resources/templates/list":
+		return &ListResourceTemplatesResult{ResourceTemplates: []interface{}{}}, nil
+	case "resources/read":
+		return s.readResource(ctx, req.Params)
+	default:
+		return nil, newError(CodeMethodNotFound, fmt.Sprintf("Method not found: %s", req.Method))
+	}
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, *Error) {
+	var p InitializeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, newError(CodeInvalidParams, "Invalid initialize params")
+	}
+
+	// Echo the client's version when we support it, otherwise offer ours and
+	// let the client decide whether to continue
+	version := LatestProtocolVersion
+	for _, supported := range supportedProtocolVersions {
+		if p.ProtocolVersion == supported {
+			version = supported
+			break
+		}
+	}
+
+	log.Printf("MCP client connected: %s %s (protocol %s)", p.ClientInfo.Name, p.ClientInfo.Version, version)
+
+	capabilities := ServerCapabilities{
+		Tools: &ToolsCapability{ListChanged: false},
+	}
+	if len(s.resources) > 0 {
+		capabilities.Resources = &ResourcesCapability{}
+	}
+
+	return &InitializeResult{
+		ProtocolVersion: version,
+		Capabilities:    capabilities,
+		ServerInfo:      s.info,
+		Instructions:    "Search and chat over indexed code repositories. Index a repository with index_repository before searching it.",
+	}, nil
+}
+
+func (s *Server) listTools() *ListToolsResult {
+	tools := make([]Tool, 0, len(s.tools))
+	for _, handler := range s.tools {
+		tools = append(tools, handler.tool)
+	}
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+	return &ListToolsResult{Tools: tools}
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (interface{}, *Error) {
+	var p CallToolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, newError(CodeInvalidParams, "Invalid tools/call params")
+	}
+
+	handler, ok := s.tools[p.Name]
+	if !ok {
+		return nil, newError(CodeInvalidParams, fmt.Sprintf("Unknown tool: %s", p.Name))
+	}
+
+	arguments := p.Arguments
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+
+	result, err := handler.call(ctx, arguments)
+	if err != nil {
+		// Argument problems are the caller's fault; everything else is
+		// reported to the model as a failed tool run
+		if rpcErr, ok := err.(*Error); ok {
+			return nil, rpcErr
+		}
+		return ErrorResult(err.Error()), nil
+	}
+	if result == nil {
+		// A nil *CallToolResult would be encoded as a null result
+		result = &CallToolResult{Content: []Content{}}
+	}
+	return result, nil
+}
+
+func errorResponse(id json.RawMessage, err *Error) *Response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &Response{JSONRPC: "2.0", ID: id, Error: err}
+}
+
+func encode(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Failed to encode MCP response: %v", err)
+		data, _ = json.Marshal(errorResponse(nil, newError(CodeInternalError, "Internal error")))
+	}
+	return data
+}
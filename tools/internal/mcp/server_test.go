@@ -0,0 +1,256 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// newTestServer returns a server with an "echo" tool that returns its text
+// argument, fails when asked to and rejects a missing argument
+func newTestServer() *Server {
+	s := NewServer(Implementation{Name: "test", Version: "1.0"})
+	s.RegisterTool(Tool{Name: "echo", InputSchema: objectSchema(map[string]interface{}{"text": stringProperty("Text to echo")}, "text")},
+		func(ctx context.Context, arguments json.RawMessage) (*CallToolResult, error) {
+			var args struct {
+				Text string `json:"text"`
+				Fail bool   `json:"fail"`
+			}
+			if err := decodeArguments(arguments, &args); err != nil {
+				return nil, err
+			}
+			if args.Text == "" {
+				return nil, newError(CodeInvalidParams, "text is required")
+			}
+			if args.Fail {
+				return nil, errors.New("echo failed")
+			}
+			return TextResult(args.Text), nil
+		})
+	s.RegisterTool(Tool{Name: "nothing"}, func(ctx context.Context, arguments json.RawMessage) (*CallToolResult, error) {
+		return nil, nil
+	})
+	return s
+}
+
+// decodeResponse decodes one response, keeping its result raw
+func decodeResponse(t *testing.T, data []byte) map[string]json.RawMessage {
+	t.Helper()
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("response %s is not a JSON object: %v", data, err)
+	}
+	return resp
+}
+
+func TestHandleMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    string
+		wantResult string
+		wantCode   int
+	}{
+		{
+			name:       "ping",
+			message:    `{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+			wantResult: `{}`,
+		},
+		{
+			name:       "tools/call",
+			message:    `{"jsonrpc":"2.0","id":"a","method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`,
+			wantResult: `{"content":[{"type":"text","text":"hi"}]}`,
+		},
+		{
+			name:       "tools/call failure is a tool result",
+			message:    `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi","fail":true}}}`,
+			wantResult: `{"content":[{"type":"text","text":"echo failed"}],"isError":true}`,
+		},
+		{
+			name:     "tools/call invalid arguments",
+			message:  `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo"}}`,
+			wantCode: CodeInvalidParams,
+		},
+		{
+			name:     "tools/call unknown tool",
+			message:  `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"missing"}}`,
+			wantCode: CodeInvalidParams,
+		},
+		{
+			name:       "tools/call without result",
+			message:    `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"nothing"}}`,
+			wantResult: `{"content":[]}`,
+		},
+		{
+			name:       "request named like a notification",
+			message:    `{"jsonrpc":"2.0","id":6,"method":"notifications/initialized"}`,
+			wantResult: `{}`,
+		},
+		{
+			name:     "unknown method",
+			message:  `{"jsonrpc":"2.0","id":7,"method":"sampling/createMessage"}`,
+			wantCode: CodeMethodNotFound,
+		},
+		{
+			name:     "parse error",
+			message:  `{"jsonrpc":"2.0","id":`,
+			wantCode: CodeParseError,
+		},
+		{
+			name:     "wrong version",
+			message:  `{"jsonrpc":"1.0","id":8,"method":"ping"}`,
+			wantCode: CodeInvalidRequest,
+		},
+		{
+			name:     "invalid initialize params",
+			message:  `{"jsonrpc":"2.0","id":9,"method":"initialize","params":[]}`,
+			wantCode: CodeInvalidParams,
+		},
+	}
+
+	s := newTestServer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := decodeResponse(t, s.HandleMessage(context.Background(), []byte(tt.message)))
+
+			if tt.wantCode != 0 {
+				var rpcErr Error
+				if err := json.Unmarshal(resp["error"], &rpcErr); err != nil || rpcErr.Code != tt.wantCode {
+					t.Fatalf("error = %s, want code %d", resp["error"], tt.wantCode)
+				}
+				if _, ok := resp["result"]; ok {
+					t.Errorf("error response has result %s", resp["result"])
+				}
+				return
+			}
+
+			result, ok := resp["result"]
+			if !ok {
+				t.Fatalf("response has no result: %v", resp)
+			}
+			if string(result) != tt.wantResult {
+				t.Errorf("result = %s, want %s", result, tt.wantResult)
+			}
+			if _, ok := resp["error"]; ok {
+				t.Errorf("successful response has error %s", resp["error"])
+			}
+		})
+	}
+}
+
+func TestHandleMessageInitialize(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     string
+		wantVersion string
+	}{
+		{"latest", LatestProtocolVersion, LatestProtocolVersion},
+		{"older supported", "2024-11-05", "2024-11-05"},
+		{"unsupported", "2099-01-01", LatestProtocolVersion},
+	}
+
+	s := newTestServer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"` + tt.version + `","capabilities":{},"clientInfo":{"name":"client","version":"0.1"}}}`
+			resp := decodeResponse(t, s.HandleMessage(context.Background(), []byte(message)))
+
+			var result InitializeResult
+			if err := json.Unmarshal(resp["result"], &result); err != nil {
+				t.Fatalf("failed to decode result %s: %v", resp["result"], err)
+			}
+			if result.ProtocolVersion != tt.wantVersion {
+				t.Errorf("protocolVersion = %q, want %q", result.ProtocolVersion, tt.wantVersion)
+			}
+			if result.ServerInfo != (Implementation{Name: "test", Version: "1.0"}) {
+				t.Errorf("serverInfo = %+v", result.ServerInfo)
+			}
+			if result.Capabilities.Tools == nil || result.Capabilities.Resources != nil {
+				t.Errorf("capabilities = %s", resp["result"])
+			}
+		})
+	}
+}
+
+func TestHandleMessageListTools(t *testing.T) {
+	s := newTestServer()
+	resp := decodeResponse(t, s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)))
+
+	var result ListToolsResult
+	if err := json.Unmarshal(resp["result"], &result); err != nil {
+		t.Fatalf("failed to decode result %s: %v", resp["result"], err)
+	}
+	var names []string
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	if want := []string{"echo", "nothing"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("tools = %v, want %v", names, want)
+	}
+}
+
+func TestHandleMessageNotification(t *testing.T) {
+	s := newTestServer()
+	for _, message := range []string{
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		// Failed notifications are not answered either
+		`{"jsonrpc":"2.0","method":"no/such/method"}`,
+		`[{"jsonrpc":"2.0","method":"notifications/initialized"}]`,
+	} {
+		if resp := s.HandleMessage(context.Background(), []byte(message)); resp != nil {
+			t.Errorf("HandleMessage(%s) = %s, want no response", message, resp)
+		}
+	}
+}
+
+func TestHandleMessageBatch(t *testing.T) {
+	s := newTestServer()
+	batch := `[
+		{"jsonrpc":"2.0","id":1,"method":"ping"},
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":2,"method":"unknown"},
+		"not a request"
+	]`
+
+	var responses []map[string]json.RawMessage
+	if err := json.Unmarshal(s.HandleMessage(context.Background(), []byte(batch)), &responses); err != nil {
+		t.Fatalf("batch response is not an array: %v", err)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("got %d responses, want 3: %v", len(responses), responses)
+	}
+
+	if id, result := string(responses[0]["id"]), string(responses[0]["result"]); id != "1" || result != "{}" {
+		t.Errorf("first response = %v", responses[0])
+	}
+	if id, ok := string(responses[1]["id"]), responses[1]["error"] != nil; id != "2" || !ok {
+		t.Errorf("second response = %v", responses[1])
+	}
+	var rpcErr Error
+	if err := json.Unmarshal(responses[2]["error"], &rpcErr); err != nil || rpcErr.Code != CodeParseError || string(responses[2]["id"]) != "null" {
+		t.Errorf("third response = %v", responses[2])
+	}
+}
+
+func TestHandleMessageInvalidBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		wantCode int
+	}{
+		{"empty", `[]`, CodeInvalidRequest},
+		{"malformed", `[{"jsonrpc":"2.0"`, CodeParseError},
+	}
+
+	s := newTestServer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := decodeResponse(t, s.HandleMessage(context.Background(), []byte(tt.message)))
+			var rpcErr Error
+			if err := json.Unmarshal(resp["error"], &rpcErr); err != nil || rpcErr.Code != tt.wantCode {
+				t.Errorf("error = %s, want code %d", resp["error"], tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package mcp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SessionHeader carries the session ID on Streamable HTTP requests
+const SessionHeader = "Mcp-Session-Id"
+
+// sessionQueueSize bounds the server-initiated messages buffered for a
+// session's standalone SSE stream
+const sessionQueueSize = 64
+
+// Session is the state of one Streamable HTTP client connection
+type Session struct {
+	ID        string
+	CreatedAt time.Time
+	// Principal is the name of the credential that opened the session; no
+	// other credential may use it
+	Principal string
+
+	mu       sync.Mutex
+	lastSeen time.Time
+	closed   bool
+	messages chan []byte
+}
+
+// Touch marks the session as active
+func (s *Session) Touch() {
+	s.mu.Lock()
+	s.lastSeen = time.Now()
+	s.mu.Unlock()
+}
+
+// Messages returns the channel of server-initiated messages for the
+// session's standalone stream. It is closed when the session ends.
+func (s *Session) Messages() <-chan []byte {
+	return s.messages
+}
+
+// Send queues a server-initiated message for the session's standalone
+// stream. Messages are dropped when nobody is listening and the queue is full.
+func (s *Session) Send(message []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	select {
+	case s.messages <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Session) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.messages)
+	}
+}
+
+func (s *Session) idleSince(cutoff time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastSeen.Before(cutoff)
+}
+
+// SessionStore tracks the live Streamable HTTP sessions and expires idle ones
+type SessionStore struct {
+	mu          sync.Mutex
+	sessions    map[string]*Session
+	idleTimeout time.Duration
+}
+
+func NewSessionStore(idleTimeout time.Duration) *SessionStore {
+	return &SessionStore{
+		sessions:    make(map[string]*Session),
+		idleTimeout: idleTimeout,
+	}
+}
+
+// Create opens a new session owned by principal with a random, unguessable ID
+func (st *SessionStore) Create(principal string) (*Session, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:        hex.EncodeToString(b),
+		CreatedAt: now,
+		Principal: principal,
+		lastSeen:  now,
+		messages:  make(chan []byte, sessionQueueSize),
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.pruneLocked()
+	st.sessions[session.ID] = session
+	return session, nil
+}
+
+// Get returns a live session and marks it active
+func (st *SessionStore) Get(id string) (*Session, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.pruneLocked()
+	session, ok := st.sessions[id]
+	if ok {
+		session.Touch()
+	}
+	return session, ok
+}
+
+// Delete ends a session, reporting whether it existed
+func (st *SessionStore) Delete(id string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	session, ok := st.sessions[id]
+	if ok {
+		session.close()
+		delete(st.sessions, id)
+	}
+	return ok
+}
+
+func (st *SessionStore) pruneLocked() {
+	if st.idleTimeout <= 0 {
+		return
+	}
+
+	cutoff := time.Now().Add(-st.idleTimeout)
+	for id, session := range st.sessions {
+		if session.idleSince(cutoff) {
+			session.close()
+			delete(st.sessions, id)
+		}
+	}
+}
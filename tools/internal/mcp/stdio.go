@@ -0,0 +1,139 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// maxStdioMessageSize bounds a single newline-delimited message on stdin
+const maxStdioMessageSize = 16 << 20
+
+// cancelledParams is the payload of notifications/cancelled
+type cancelledParams struct {
+	RequestID json.RawMessage `json:"requestId"`
+	Reason    string          `json:"reason"`
+}
+
+// ServeStdio runs the MCP stdio transport: newline-delimited JSON-RPC messages
+// are read from in and responses written to out, one per line. Requests are
+// handled concurrently so a long tool call does not block pings, and
+// notifications/cancelled aborts the matching in-flight request. It returns
+// when in reaches EOF or ctx is done.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		writeMu  sync.Mutex
+		wg       sync.WaitGroup
+		flightMu sync.Mutex
+		inFlight = make(map[string]context.CancelFunc)
+	)
+
+	write := func(data []byte) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+
+		if _, err := out.Write(append(data, '\n')); err != nil {
+			log.Printf("Failed to write MCP message: %v", err)
+		}
+	}
+
+	ctx = WithNotifier(ctx, func(notification *Notification) {
+		write(encode(notification))
+	})
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStdioMessageSize)
+
+	lines := make(chan []byte)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		scanErr <- scanner.Err()
+	}()
+
+	log.Printf("MCP stdio transport ready")
+
+	for {
+		var line []byte
+		var ok bool
+		select {
+		case line, ok = <-lines:
+		case <-ctx.Done():
+		}
+		if !ok {
+			break
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		var req Request
+		if err := json.Unmarshal(line, &req); err == nil && req.Method == "notifications/cancelled" {
+			var params cancelledParams
+			if err := json.Unmarshal(req.Params, &params); err == nil {
+				flightMu.Lock()
+				if cancelRequest, found := inFlight[string(params.RequestID)]; found {
+					log.Printf("Cancelling MCP request %s: %s", params.RequestID, params.Reason)
+					cancelRequest()
+				}
+				flightMu.Unlock()
+			}
+			continue
+		}
+
+		reqCtx, cancelRequest := context.WithCancel(ctx)
+		key := string(req.ID)
+		if key != "" {
+			flightMu.Lock()
+			inFlight[key] = cancelRequest
+			flightMu.Unlock()
+		}
+
+		wg.Add(1)
+		go func(line []byte) {
+			defer wg.Done()
+			defer func() {
+				if key != "" {
+					flightMu.Lock()
+					delete(inFlight, key)
+					flightMu.Unlock()
+				}
+				cancelRequest()
+			}()
+
+			if response := s.HandleMessage(reqCtx, line); response != nil {
+				write(response)
+			}
+		}(line)
+	}
+
+	// The client has gone away, so abandon in-flight requests and wait for
+	// their goroutines. Background indexing jobs are unaffected.
+	err := ctx.Err()
+	cancel()
+	wg.Wait()
+
+	select {
+	case scanErr := <-scanErr:
+		if scanErr != nil {
+			return fmt.Errorf("failed to read MCP input: %w", scanErr)
+		}
+	default:
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stdioClient drives ServeStdio over pipes like a client process would
+type stdioClient struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *bufio.Reader
+	served chan error
+}
+
+func newStdioClient(t *testing.T, s *Server) *stdioClient {
+	t.Helper()
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+
+	c := &stdioClient{t: t, in: inWriter, out: bufio.NewReader(outReader), served: make(chan error, 1)}
+	go func() {
+		c.served <- s.ServeStdio(context.Background(), inReader, outWriter)
+		outWriter.Close()
+	}()
+	t.Cleanup(func() { inWriter.Close() })
+	return c
+}
+
+func (c *stdioClient) send(message string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, message+"\n"); err != nil {
+		c.t.Fatalf("failed to send %s: %v", message, err)
+	}
+}
+
+// receive reads the next line the server writes
+func (c *stdioClient) receive() map[string]json.RawMessage {
+	c.t.Helper()
+	line, err := c.out.ReadString('\n')
+	if err != nil {
+		c.t.Fatalf("failed to read response: %v", err)
+	}
+	if !strings.HasSuffix(line, "\n") || strings.Count(line, "\n") != 1 {
+		c.t.Fatalf("response %q is not a single line", line)
+	}
+	return decodeResponse(c.t, []byte(line))
+}
+
+func TestServeStdio(t *testing.T) {
+	c := newStdioClient(t, newTestServer())
+
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"client","version":"0.1"}}}`)
+	if resp := c.receive(); string(resp["id"]) != "1" || resp["result"] == nil {
+		t.Fatalf("initialize response = %v", resp)
+	}
+
+	// Neither the notification nor the blank line is answered, so the next
+	// line is the response to the ping
+	c.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	c.send(``)
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	if resp := c.receive(); string(resp["id"]) != "2" || string(resp["result"]) != "{}" {
+		t.Fatalf("ping response = %v", resp)
+	}
+
+	for i, text := range []string{"first", "second", "third"} {
+		id := string(rune('3' + i))
+		c.send(`{"jsonrpc":"2.0","id":` + id + `,"method":"tools/call","params":{"name":"echo","arguments":{"text":"` + text + `"}}}`)
+		resp := c.receive()
+		var result CallToolResult
+		if err := json.Unmarshal(resp["result"], &result); err != nil || string(resp["id"]) != id || result.Content[0].Text != text {
+			t.Fatalf("response to request %s = %v", id, resp)
+		}
+	}
+
+	c.send(`not json`)
+	var rpcErr Error
+	if resp := c.receive(); json.Unmarshal(resp["error"], &rpcErr) != nil || rpcErr.Code != CodeParseError {
+		t.Fatalf("response to invalid JSON = %v", resp)
+	}
+
+	// Closing stdin ends the transport cleanly
+	c.in.Close()
+	select {
+	case err := <-c.served:
+		if err != nil {
+			t.Errorf("ServeStdio() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeStdio() did not return after stdin was closed")
+	}
+	if _, err := c.out.ReadString('\n'); err != io.EOF {
+		t.Errorf("server wrote after the last response: %v", err)
+	}
+}
+
+func TestServeStdioCancelsRequest(t *testing.T) {
+	s := newTestServer()
+	started := make(chan struct{})
+	s.RegisterTool(Tool{Name: "block"}, func(ctx context.Context, arguments json.RawMessage) (*CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	c := newStdioClient(t, s)
+
+	c.send(`{"jsonrpc":"2.0","id":"slow","method":"tools/call","params":{"name":"block"}}`)
+	<-started
+
+	// A blocked request does not hold up others
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+	if resp := c.receive(); string(resp["id"]) != "1" {
+		t.Fatalf("ping response = %v", resp)
+	}
+
+	c.send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"slow","reason":"user"}}`)
+	resp := c.receive()
+	var result CallToolResult
+	if err := json.Unmarshal(resp["result"], &result); err != nil || string(resp["id"]) != `"slow"` || !result.IsError {
+		t.Fatalf("cancelled response = %v", resp)
+	}
+}
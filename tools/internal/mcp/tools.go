@@ -0,0 +1,341 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mcpserver/internal/models"
+	"mcpserver/internal/service"
+	"mcpserver/pkg/git"
+	"mcpserver/pkg/language"
+)
+
+// ToolFunc runs a tool with its raw JSON arguments. Returning an *Error
+// produces a JSON-RPC error; any other error becomes a failed tool result.
+type ToolFunc func(ctx context.Context, arguments json.RawMessage) (*CallToolResult, error)
+
+type toolHandler struct {
+	tool Tool
+	call ToolFunc
+}
+
+// RegisterTool exposes a tool to clients, replacing any tool with the same name
+func (s *Server) RegisterTool(tool Tool, call ToolFunc) {
+	s.tools[tool.Name] = &toolHandler{tool: tool, call: call}
+}
+
+// jobPollInterval is how often index_repository checks on a job it waits for
+const jobPollInterval = time.Second
+
+type vectorSearchArgs struct {
+	Query      string   `json:"query"`
+	Repository string   `json:"repository"`
+	Branch     string   `json:"branch"`
+	Limit      int      `json:"limit"`
+	Languages  []string `json:"languages"`
+
+	Documentation models.DocumentationMode `json:"documentation"`
+}
+
+type indexRepositoryArgs struct {
+	RepoURL string `json:"repoUrl"`
+	Branch  string `json:"branch"`
+	Wait    bool   `json:"wait"`
+	models.IndexFilter
+}
+
+type indexStatusArgs struct {
+	JobID string `json:"jobId"`
+}
+
+type chatArgs struct {
+	Message    string                 `json:"message"`
+	Repository string                 `json:"repository"`
+	Context    map[string]interface{} `json:"context"`
+}
+
+// RegisterServiceTools exposes the search, indexing and chat services as tools
+func RegisterServiceTools(s *Server, vectorSearch *service.VectorSearchService, repoIndexer *service.RepoIndexerService, mcpService *service.MCPServerService) {
+	s.RegisterTool(Tool{
+		Name:        "vector_search",
+		Description: "Semantic search over the code of an indexed repository. Returns the most relevant code chunks.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"query":      stringProperty("Natural language or code search query"),
+			"repository": stringProperty("Repository in owner/name form"),
+			"branch":     stringProperty("Branch to search (default: main)"),
+			"limit":      integerProperty("Maximum number of chunks to return (default: 10)", 1, 100),
+			"languages": map[string]interface{}{
+				"type":        "array",
+				"description": "Only return chunks in these languages",
+				"items":       map[string]interface{}{"type": "string", "enum": language.Names()},
+			},
+			"documentation": map[string]interface{}{
+				"type":        "string",
+				"description": "How to treat READMEs and other docs: include them (default), exclude them, return only docs, or prefer docs over code",
+				"enum":        []string{"include", "exclude", "only", "prefer"},
+			},
+		}, "query", "repository"),
+	}, func(ctx context.Context, arguments json.RawMessage) (*CallToolResult, error) {
+		var args vectorSearchArgs
+		if err := decodeArguments(arguments, &args); err != nil {
+			return nil, err
+		}
+		if args.Query == "" || args.Repository == "" {
+			return nil, newError(CodeInvalidParams, "query and repository are required")
+		}
+
+		resp, err := vectorSearch.Search(ctx, &models.SearchRequest{
+			Query:      args.Query,
+			Repository: args.Repository,
+			Branch:     args.Branch,
+			Limit:      args.Limit,
+			Languages:  args.Languages,
+
+			Documentation: args.Documentation,
+		})
+		if errors.Is(err, service.ErrUnknownLanguage) || errors.Is(err, service.ErrInvalidDocumentationMode) {
+			return nil, newError(CodeInvalidParams, err.Error())
+		}
+		if err != nil {
+			return nil, err
+		}
+		return TextResult(formatChunks(resp.Chunks)), nil
+	})
+
+	s.RegisterTool(Tool{
+		Name:        "index_repository",
+		Description: "Clone a Git repository and index its code for search. Runs as a background job unless wait is set.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"repoUrl": stringProperty("Clone URL of the repository"),
+			"branch":  stringProperty("Branch, tag or full commit SHA to index (default: main)"),
+			"include": stringArrayProperty("Only index files matching these .gitignore-style patterns"),
+			"exclude": stringArrayProperty("Skip files and directories matching these .gitignore-style patterns"),
+			"maxFileSize": map[string]interface{}{
+				"type":        "integer",
+				"description": "Skip files larger than this many bytes",
+			},
+			"wait": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Wait for indexing to finish instead of returning the job ID immediately",
+			},
+		}, "repoUrl"),
+	}, func(ctx context.Context, arguments json.RawMessage) (*CallToolResult, error) {
+		var args indexRepositoryArgs
+		if err := decodeArguments(arguments, &args); err != nil {
+			return nil, err
+		}
+		if args.RepoURL == "" {
+			return nil, newError(CodeInvalidParams, "repoUrl is required")
+		}
+		if args.Branch == "" {
+			args.Branch = "main"
+		}
+
+		job, err := repoIndexer.StartIndexJob(ctx, args.RepoURL, args.Branch, args.IndexFilter)
+		if errors.Is(err, git.ErrInvalidRemote) || errors.Is(err, git.ErrInvalidRef) {
+			return nil, newError(CodeInvalidParams, err.Error())
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !args.Wait {
+			return jsonResult(job)
+		}
+
+		job, err = waitForJob(ctx, repoIndexer, job.ID)
+		if err != nil {
+			return nil, err
+		}
+		if job.Status == models.IndexJobFailed {
+			result, err := jsonResult(job)
+			if result != nil {
+				result.IsError = true
+			}
+			return result, err
+		}
+		return jsonResult(job)
+	})
+
+	s.RegisterTool(Tool{
+		Name:        "index_status",
+		Description: "Report the status and progress of a repository indexing job.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"jobId": stringProperty("Job ID returned by index_repository"),
+		}, "jobId"),
+	}, func(ctx context.Context, arguments json.RawMessage) (*CallToolResult, error) {
+		var args indexStatusArgs
+		if err := decodeArguments(arguments, &args); err != nil {
+			return nil, err
+		}
+		if args.JobID == "" {
+			return nil, newError(CodeInvalidParams, "jobId is required")
+		}
+
+		job, err := repoIndexer.GetIndexJob(ctx, args.JobID)
+		if err != nil {
+			return nil, err
+		}
+		return jsonResult(job)
+	})
+
+	s.RegisterTool(Tool{
+		Name:        "purge_index",
+		Description: "Remove an indexed repository, one of its branches, or files of a branch from the index. Reports how many vectors were deleted.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"repository": stringProperty("Repository in owner/name form"),
+			"branch":     stringProperty("Branch to purge; omit to purge every branch of the repository"),
+			"paths":      stringArrayProperty("Only purge these files of the branch, as paths relative to the repository root"),
+		}, "repository"),
+	}, func(ctx context.Context, arguments json.RawMessage) (*CallToolResult, error) {
+		var req models.PurgeRequest
+		if err := decodeArguments(arguments, &req); err != nil {
+			return nil, err
+		}
+		if req.Repository == "" {
+			return nil, newError(CodeInvalidParams, "repository is required")
+		}
+
+		result, err := repoIndexer.PurgeIndex(ctx, req)
+		if errors.Is(err, service.ErrInvalidRepository) || errors.Is(err, service.ErrPurgePathsNeedBranch) || errors.Is(err, service.ErrInvalidPurgePath) {
+			return nil, newError(CodeInvalidParams, err.Error())
+		}
+		if err != nil {
+			return nil, err
+		}
+		return jsonResult(result)
+	})
+
+	s.RegisterTool(Tool{
+		Name:        "chat",
+		Description: "Ask a question about an indexed repository and get back the relevant code context.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"message":    stringProperty("Question about the repository"),
+			"repository": stringProperty("Repository in owner/name form"),
+			"context": map[string]interface{}{
+				"type":        "object",
+				"description": "Additional client context; set \"documentation\" to include, exclude, only or prefer to control whether docs are searched",
+			},
+		}, "message", "repository"),
+	}, func(ctx context.Context, arguments json.RawMessage) (*CallToolResult, error) {
+		var args chatArgs
+		if err := decodeArguments(arguments, &args); err != nil {
+			return nil, err
+		}
+		if args.Message == "" || args.Repository == "" {
+			return nil, newError(CodeInvalidParams, "message and repository are required")
+		}
+
+		result, err := mcpService.HandleChat(ctx, args.Message, args.Repository, args.Context)
+		if err != nil {
+			return nil, err
+		}
+		return jsonResult(result)
+	})
+}
+
+// waitForJob polls a job until it finishes or ctx is done, reporting progress
+// as files are processed. Giving up on the wait leaves the job running.
+func waitForJob(ctx context.Context, repoIndexer *service.RepoIndexerService, jobID string) (*models.IndexJob, error) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	lastSeen := -1
+	for {
+		job, err := repoIndexer.GetIndexJob(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if job.FinishedAt != nil {
+			return job, nil
+		}
+
+		// The total is unknown until the walk ends, so report files seen,
+		// which only ever grows
+		if job.Report.FilesSeen > lastSeen {
+			lastSeen = job.Report.FilesSeen
+			ReportProgress(ctx, float64(lastSeen), 0, fmt.Sprintf("%s: %d files processed, %d skipped, %d chunks stored",
+				job.Status, job.Report.FilesProcessed, job.Report.FilesSkipped, job.Report.ChunksStored))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for job %s, which is still running: %w", jobID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func decodeArguments(arguments json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(arguments, v); err != nil {
+		return newError(CodeInvalidParams, fmt.Sprintf("Invalid arguments: %v", err))
+	}
+	return nil
+}
+
+func jsonResult(v interface{}) (*CallToolResult, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	return TextResult(string(data)), nil
+}
+
+func formatChunks(chunks []models.CodeChunk) string {
+	if len(chunks) == 0 {
+		return "No matching code found."
+	}
+
+	var b strings.Builder
+	for i, chunk := range chunks {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		location := chunk.FilePath
+		if chunk.StartLine > 0 {
+			location = fmt.Sprintf("%s:%d-%d", chunk.FilePath, chunk.StartLine, chunk.EndLine)
+		}
+		if len(chunk.Headings) > 0 {
+			location = fmt.Sprintf("%s, %s", location, strings.Join(chunk.Headings, " > "))
+		} else if chunk.Symbol != "" {
+			location = fmt.Sprintf("%s, %s %s", location, chunk.SymbolKind, chunk.Symbol)
+		}
+		fmt.Fprintf(&b, "File: %s (%s)\n```\n%s\n```\n", location, chunk.Language, chunk.Content)
+	}
+	return b.String()
+}
+
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": description,
+	}
+}
+
+func stringArrayProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "string"},
+		"description": description,
+	}
+}
+
+func integerProperty(description string, minimum, maximum int) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "integer",
+		"description": description,
+		"minimum":     minimum,
+		"maximum":     maximum,
+	}
+}
@@ -1,13 +1,31 @@
 package models
 
+import "time"
+
 // CodeChunk represents a chunk of code with metadata
 type CodeChunk struct {
-	Content    string    `json:"content"`
-	FilePath   string    `json:"filePath"`
-	Repository string    `json:"repository"`
-	Branch     string    `json:"branch"`
-	Language   string    `json:"language"`
-	Embedding  []float32 `json:"embedding"`
+	Content    string `json:"content"`
+	FilePath   string `json:"filePath"`
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	// Commit is the SHA the chunk was indexed at, when it came from Git
+	Commit     string `json:"commit,omitempty"`
+	Language   string `json:"language"`
+	ChunkIndex int    `json:"chunkIndex"`
+	// StartLine and EndLine are the 1-based, inclusive lines of the file
+	// the chunk holds
+	StartLine int `json:"startLine,omitempty"`
+	EndLine   int `json:"endLine,omitempty"`
+	// Symbol and SymbolKind name the declaration the chunk belongs to, such
+	// as a function or type, when it belongs to exactly one
+	Symbol     string `json:"symbol,omitempty"`
+	SymbolKind string `json:"symbolKind,omitempty"`
+	// Headings are the titles of the document sections enclosing a
+	// documentation chunk, outermost first
+	Headings []string `json:"headings,omitempty"`
+	// Documentation marks chunks of prose, such as READMEs, rather than code
+	Documentation bool      `json:"documentation,omitempty"`
+	Embedding     []float32 `json:"embedding"`
 }
 
 // SearchRequest represents a vector search request
@@ -16,8 +34,28 @@ type SearchRequest struct {
 	Repository string `json:"repository"`
 	Branch     string `json:"branch"`
 	Limit      int    `json:"limit"`
+	// Languages restricts results to chunks of these languages when set
+	Languages []string `json:"languages,omitempty"`
+	// Documentation chooses how documentation chunks are treated; empty
+	// means DocumentationInclude
+	Documentation DocumentationMode `json:"documentation,omitempty"`
 }
 
+// DocumentationMode chooses whether a search returns documentation chunks
+type DocumentationMode string
+
+const (
+	// DocumentationInclude ranks documentation and code together
+	DocumentationInclude DocumentationMode = "include"
+	// DocumentationExclude returns code only
+	DocumentationExclude DocumentationMode = "exclude"
+	// DocumentationOnly returns documentation only
+	DocumentationOnly DocumentationMode = "only"
+	// DocumentationPrefer returns documentation first and fills the rest of
+	// the limit with code
+	DocumentationPrefer DocumentationMode = "prefer"
+)
+
 // SearchResponse represents a vector search response
 type SearchResponse struct {
 	Chunks []CodeChunk `json:"chunks"`
@@ -44,7 +82,141 @@ type ServerInfo struct {
 // IndexRepositoryRequest represents a repository indexing request
 type IndexRepositoryRequest struct {
 	RepoURL string `json:"repoUrl"`
-	Branch  string `json:"branch"`
+	// Path is a directory on the server to index instead of a Git remote
+	Path string `json:"path"`
+	// Repository names what a path is indexed as and is required with Path
+	Repository string `json:"repository"`
+	// Branch is the branch, tag or full commit SHA to index
+	Branch string `json:"branch"`
+	IndexFilter
+}
+
+// IndexFilter narrows which files of a repository are indexed. Patterns use
+// .gitignore syntax and are matched against paths relative to the
+// repository root.
+type IndexFilter struct {
+	// Include, when set, limits indexing to files matching one of its patterns
+	Include []string `json:"include,omitempty"`
+	// Exclude skips files and directories matching any of its patterns
+	Exclude []string `json:"exclude,omitempty"`
+	// MaxFileSize skips files larger than this many bytes; zero keeps the
+	// server default
+	MaxFileSize int64 `json:"maxFileSize,omitempty"`
+}
+
+// IndexMode describes how much of a repository an indexing run covered
+type IndexMode string
+
+const (
+	// IndexModeFull re-indexes every file in the repository
+	IndexModeFull IndexMode = "full"
+	// IndexModeIncremental re-indexes only files changed since the last run
+	IndexModeIncremental IndexMode = "incremental"
+	// IndexModeUnchanged means the indexed commit was already up to date
+	IndexModeUnchanged IndexMode = "unchanged"
+)
+
+// IndexReport summarises the work done by a repository indexing run
+type IndexReport struct {
+	Mode           IndexMode `json:"mode,omitempty"`
+	Commit         string    `json:"commit,omitempty"`
+	FilesSeen      int       `json:"filesSeen"`
+	FilesSkipped   int       `json:"filesSkipped"`
+	FilesProcessed int       `json:"filesProcessed"`
+	FilesDeleted   int       `json:"filesDeleted"`
+	ChunksStored   int       `json:"chunksStored"`
+	// SkipReasons counts skipped files by why they were skipped
+	SkipReasons map[string]int `json:"skipReasons,omitempty"`
+	// SkippedFiles lists the first skipped files with their reasons
+	SkippedFiles []SkippedFile `json:"skippedFiles,omitempty"`
+	Errors       []string      `json:"errors"`
+}
+
+// SkippedFile is a file an indexing run left out
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// IndexSource is where the files of an indexing job come from
+type IndexSource string
+
+const (
+	// IndexSourceGit clones a Git remote
+	IndexSourceGit IndexSource = "git"
+	// IndexSourcePath reads a directory on the server
+	IndexSourcePath IndexSource = "path"
+	// IndexSourceArchive unpacks an uploaded tar.gz or zip archive
+	IndexSourceArchive IndexSource = "archive"
+)
+
+// IndexJobStatus is the lifecycle state of an indexing job
+type IndexJobStatus string
+
+const (
+	IndexJobQueued    IndexJobStatus = "queued"
+	IndexJobRunning   IndexJobStatus = "running"
+	IndexJobCompleted IndexJobStatus = "completed"
+	IndexJobFailed    IndexJobStatus = "failed"
+	IndexJobCancelled IndexJobStatus = "cancelled"
+)
+
+// IndexJob represents a background repository indexing job
+type IndexJob struct {
+	ID         string         `json:"id"`
+	Source     IndexSource    `json:"source"`
+	RepoURL    string         `json:"repoUrl,omitempty"`
+	Path       string         `json:"path,omitempty"`
+	Repository string         `json:"repository"`
+	Branch     string         `json:"branch"`
+	Status     IndexJobStatus `json:"status"`
+	Report     IndexReport    `json:"report"`
+	Error      string         `json:"error,omitempty"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	StartedAt  *time.Time     `json:"startedAt,omitempty"`
+	FinishedAt *time.Time     `json:"finishedAt,omitempty"`
+}
+
+// IndexJobRequest identifies an indexing job
+type IndexJobRequest struct {
+	JobID string `json:"jobId"`
+}
+
+// PurgeRequest selects indexed data to remove: a whole repository, one of
+// its branches, or files of a branch
+type PurgeRequest struct {
+	Repository string   `json:"repository"`
+	Branch     string   `json:"branch,omitempty"`
+	Paths      []string `json:"paths,omitempty"`
+}
+
+// PurgeResult reports what a purge removed
+type PurgeResult struct {
+	Repository string   `json:"repository"`
+	Branch     string   `json:"branch,omitempty"`
+	Paths      []string `json:"paths,omitempty"`
+	Deleted    int      `json:"deleted"`
+}
+
+// IndexedRepository is a catalog entry listing what has been indexed of a
+// repository
+type IndexedRepository struct {
+	Repository string          `json:"repository"`
+	Branches   []IndexedBranch `json:"branches"`
+}
+
+// IndexedBranch describes the last successful index of a repository branch
+type IndexedBranch struct {
+	Branch string      `json:"branch"`
+	Source IndexSource `json:"source"`
+	// Commit is the SHA that was indexed, when the branch came from Git
+	Commit string `json:"commit,omitempty"`
+	Files  int    `json:"files"`
+	Chunks int    `json:"chunks"`
+	// Languages counts the indexed files of each language
+	Languages      map[string]int `json:"languages"`
+	EmbeddingModel string         `json:"embeddingModel"`
+	IndexedAt      time.Time      `json:"indexedAt"`
 }
 
 // ChatRequest represents a chat request
@@ -65,6 +237,16 @@ type CursorRequest struct {
 type GitHubConfigRequest struct {
 	Repository string `json:"repository"`
 	Token      string `json:"token"`
+	SSHKey     string `json:"sshKey"`
+}
+
+// GitHubCredential describes a stored repository token without revealing it
+type GitHubCredential struct {
+	Repository string    `json:"repository"`
+	HasToken   bool      `json:"hasToken"`
+	HasSSHKey  bool      `json:"hasSshKey"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
 }
 
 // APIResponse represents a standard API response
@@ -72,4 +254,4 @@ type APIResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data"`
 	Message string      `json:"message"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"mcpserver/internal/models"
+	"mcpserver/internal/storage"
+)
+
+// chunkBatcher buffers chunks produced while walking a repository, embeds them
+// with multi-input embedding requests and upserts them in bulk
+type chunkBatcher struct {
+	embedder        storage.Embedder
+	vectorStore     storage.VectorStore
+	embedBatchSize  int
+	upsertBatchSize int
+	progress        *indexProgress
+
+	pending []models.CodeChunk
+	stored  int
+	// err is the failure of an earlier flush, after which the run cannot
+	// produce a complete index
+	err error
+}
+
+func newChunkBatcher(embedder storage.Embedder, vectorStore storage.VectorStore, embedBatchSize, upsertBatchSize int, progress *indexProgress) *chunkBatcher {
+	return &chunkBatcher{
+		embedder:        embedder,
+		vectorStore:     vectorStore,
+		embedBatchSize:  embedBatchSize,
+		upsertBatchSize: upsertBatchSize,
+		progress:        progress,
+	}
+}
+
+// Add queues chunks and flushes once a full embedding batch is pending
+func (b *chunkBatcher) Add(chunks ...models.CodeChunk) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.pending = append(b.pending, chunks...)
+	if len(b.pending) < b.embedBatchSize {
+		return nil
+	}
+	return b.Flush()
+}
+
+// Err returns the failure of an earlier flush, if any. The run should stop
+// once there is one.
+func (b *chunkBatcher) Err() error {
+	return b.err
+}
+
+// Flush embeds and stores every pending chunk. When a batch fails, every file
+// with chunks in it or after it is reported in progress as failed, since its
+// vectors are incomplete, and the batcher refuses further chunks.
+func (b *chunkBatcher) Flush() error {
+	if b.err != nil {
+		return b.err
+	}
+
+	pending := b.pending
+	b.pending = nil
+
+	for start := 0; start < len(pending); start += b.embedBatchSize {
+		batch := pending[start:min(start+b.embedBatchSize, len(pending))]
+
+		texts := make([]string, len(batch))
+		for i, chunk := range batch {
+			texts[i] = chunk.Content
+		}
+
+		embeddings, err := b.embedder.GetEmbeddings(texts)
+		if err != nil {
+			return b.fail(pending[start:], fmt.Errorf("failed to get embeddings for %d chunks: %w", len(batch), err))
+		}
+		for i := range batch {
+			batch[i].Embedding = embeddings[i]
+		}
+
+		for upsertStart := 0; upsertStart < len(batch); upsertStart += b.upsertBatchSize {
+			upsert := batch[upsertStart:min(upsertStart+b.upsertBatchSize, len(batch))]
+			if err := b.vectorStore.StoreBatch(upsert); err != nil {
+				return b.fail(pending[start+upsertStart:], fmt.Errorf("failed to store %d chunks: %w", len(upsert), err))
+			}
+			b.stored += len(upsert)
+			b.progress.chunksStored(len(upsert))
+		}
+
+		fmt.Printf("Stored batch of %d chunks (%d total)\n", len(batch), b.stored)
+	}
+
+	return nil
+}
+
+// fail records that the chunks in unstored were dropped by err. Files with
+// chunks among them no longer count as processed.
+func (b *chunkBatcher) fail(unstored []models.CodeChunk, err error) error {
+	var files []string
+	seen := make(map[string]bool)
+	for _, chunk := range unstored {
+		if !seen[chunk.FilePath] {
+			seen[chunk.FilePath] = true
+			files = append(files, chunk.FilePath)
+		}
+	}
+
+	b.err = fmt.Errorf("%w (files: %s)", err, strings.Join(files, ", "))
+	b.progress.filesFailed(files, "storing chunks failed")
+	b.progress.addError("%v", b.err)
+	return b.err
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mcpserver/internal/models"
+	"mcpserver/internal/storage"
+)
+
+// failingEmbedder embeds with a hashing embedder until failAfter requests
+// have been made, then fails every request
+type failingEmbedder struct {
+	*storage.HashingEmbedder
+	failAfter int
+	calls     int
+}
+
+func (e *failingEmbedder) GetEmbeddings(texts []string) ([][]float32, error) {
+	e.calls++
+	if e.calls > e.failAfter {
+		return nil, errors.New("embedding service unavailable")
+	}
+	return e.HashingEmbedder.GetEmbeddings(texts)
+}
+
+func TestProcessDirectoryBatchFailure(t *testing.T) {
+	tests := []struct {
+		name          string
+		failAfter     int
+		wantErr       bool
+		wantProcessed int
+		wantSkipped   int
+		wantStored    int
+		wantCataloged int
+	}{
+		{"all batches stored", 10, false, 3, 0, 3, 3},
+		// a.go and b.go share the first batch; the walk stops before c.go
+		{"first batch fails", 0, true, 0, 2, 0, 0},
+		// c.go alone is in the final batch
+		{"final batch fails", 1, true, 2, 1, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range []string{"a.go", "b.go", "c.go"} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("package main\n\nfunc "+name[:1]+"() {}\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			store := storage.NewMemoryStore()
+			embedder := &failingEmbedder{HashingEmbedder: storage.NewHashingEmbedder(8), failAfter: tt.failAfter}
+			ri := NewRepoIndexerService(store, embedder, nil, nil, nil, nil, IndexerOptions{EmbeddingBatchSize: 2})
+			filter, err := newFileFilter(dir, ri.effectiveFilter("acme/api", models.IndexFilter{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			progress := &indexProgress{}
+			err = ri.processDirectory(context.Background(), dir, "acme/api", "main", filter, progress)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("processDirectory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			report := progress.snapshot()
+			if report.FilesProcessed != tt.wantProcessed || report.FilesSkipped != tt.wantSkipped || report.ChunksStored != tt.wantStored {
+				t.Errorf("processed %d, skipped %d, stored %d; want %d, %d, %d",
+					report.FilesProcessed, report.FilesSkipped, report.ChunksStored, tt.wantProcessed, tt.wantSkipped, tt.wantStored)
+			}
+			if tt.wantErr && len(report.Errors) != 1 {
+				t.Errorf("errors = %q, want the failed batch reported once", report.Errors)
+			}
+			if got := len(progress.catalogUpdate().Files); got != tt.wantCataloged {
+				t.Errorf("cataloged %d files, want %d", got, tt.wantCataloged)
+			}
+
+			ids, err := store.List("acme/api@")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ids) != tt.wantStored {
+				t.Errorf("store holds %d vectors, want %d", len(ids), tt.wantStored)
+			}
+		})
+	}
+}
+
+func TestChunkBatcherRefusesChunksAfterFailure(t *testing.T) {
+	embedder := &failingEmbedder{HashingEmbedder: storage.NewHashingEmbedder(8)}
+	batcher := newChunkBatcher(embedder, storage.NewMemoryStore(), 1, 1, &indexProgress{})
+
+	if err := batcher.Add(models.CodeChunk{FilePath: "a.go", Content: "a"}); err == nil {
+		t.Fatal("Add() succeeded, want the embedding failure")
+	}
+	if batcher.Err() == nil {
+		t.Fatal("Err() = nil after a failed flush")
+	}
+	if err := batcher.Add(models.CodeChunk{FilePath: "b.go", Content: "b"}); err == nil {
+		t.Error("Add() after a failure succeeded")
+	}
+	if embedder.calls != 1 {
+		t.Errorf("embedder called %d times, want 1", embedder.calls)
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"mcpserver/internal/auth"
+	"mcpserver/internal/models"
+)
+
+// ListRepositories returns the catalog of indexed repositories, with only
+// the branches the caller in ctx may read
+func (ri *RepoIndexerService) ListRepositories(ctx context.Context) []models.IndexedRepository {
+	return ri.catalog.Repositories(func(repository, branch string) bool {
+		return ri.policy.Allowed(ctx, auth.ActionRead, repository, branch)
+	})
+}
+
+// recordCatalog adds a finished run to the catalog of indexed branches
+func (ri *RepoIndexerService) recordCatalog(repository, branch string, source models.IndexSource, progress *indexProgress) error {
+	update := progress.catalogUpdate()
+	update.Source = source
+	update.EmbeddingModel = ri.embedder.Model()
+	update.IndexedAt = time.Now()
+
+	if err := ri.catalog.Update(repository, branch, update); err != nil {
+		return fmt.Errorf("failed to update catalog: %w", err)
+	}
+	return nil
+}
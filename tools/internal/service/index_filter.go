@@ -0,0 +1,164 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"mcpserver/internal/models"
+	"mcpserver/pkg/ignore"
+)
+
+// defaultMaxFileSize is the largest file indexed when no filter sets a limit
+const defaultMaxFileSize = 100000
+
+// LoadIndexRules reads per-repository index filters from a JSON file mapping
+// repository patterns to filters. Patterns are "owner/name" with path.Match
+// wildcards, such as "acme/*"; a bare "*" matches every repository. An empty
+// path means no rules.
+func LoadIndexRules(filePath string) (map[string]models.IndexFilter, error) {
+	if filePath == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index rules: %w", err)
+	}
+
+	var rules map[string]models.IndexFilter
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse index rules %s: %w", filePath, err)
+	}
+	for pattern, rule := range rules {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid repository pattern %q in index rules: %w", pattern, err)
+		}
+		if err := validateIndexFilter(rule); err != nil {
+			return nil, fmt.Errorf("index rules for %q: %w", pattern, err)
+		}
+	}
+	return rules, nil
+}
+
+// validateIndexFilter reports invalid include or exclude patterns
+func validateIndexFilter(filter models.IndexFilter) error {
+	if _, err := ignore.Compile(filter.Include); err != nil {
+		return fmt.Errorf("include: %w", err)
+	}
+	if _, err := ignore.Compile(filter.Exclude); err != nil {
+		return fmt.Errorf("exclude: %w", err)
+	}
+	if filter.MaxFileSize < 0 {
+		return fmt.Errorf("maxFileSize must not be negative")
+	}
+	return nil
+}
+
+// effectiveFilter combines the server-wide filter, the rules of every
+// matching repository pattern and the filter of the request. Excludes add
+// up, while the request's include list and size limit override the rules'.
+func (ri *RepoIndexerService) effectiveFilter(repository string, request models.IndexFilter) models.IndexFilter {
+	filter := models.IndexFilter{
+		Exclude:     append([]string(nil), ri.options.Filter.Exclude...),
+		Include:     append([]string(nil), ri.options.Filter.Include...),
+		MaxFileSize: ri.options.Filter.MaxFileSize,
+	}
+
+	// Apply rules in a stable order so the filter fingerprint is too
+	patterns := make([]string, 0, len(ri.options.RepositoryFilters))
+	for pattern := range ri.options.RepositoryFilters {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, repository); !ok && pattern != "*" {
+			continue
+		}
+		rule := ri.options.RepositoryFilters[pattern]
+		filter.Exclude = append(filter.Exclude, rule.Exclude...)
+		filter.Include = append(filter.Include, rule.Include...)
+		if rule.MaxFileSize > 0 {
+			filter.MaxFileSize = rule.MaxFileSize
+		}
+	}
+
+	filter.Exclude = append(filter.Exclude, request.Exclude...)
+	if len(request.Include) > 0 {
+		filter.Include = request.Include
+	}
+	if request.MaxFileSize > 0 {
+		filter.MaxFileSize = request.MaxFileSize
+	}
+	if filter.MaxFileSize <= 0 {
+		filter.MaxFileSize = defaultMaxFileSize
+	}
+	return filter
+}
+
+// fileFilter decides which files of a checked-out tree are indexed
+type fileFilter struct {
+	gitignore   *ignore.Matcher
+	include     *ignore.Matcher
+	exclude     *ignore.Matcher
+	maxFileSize int64
+	fingerprint string
+}
+
+// newFileFilter compiles filter and loads the .gitignore files of the tree
+// at dir
+func newFileFilter(dir string, filter models.IndexFilter) (*fileFilter, error) {
+	include, err := ignore.Compile(filter.Include)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern: %w", err)
+	}
+	exclude, err := ignore.Compile(filter.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+	gitignore, err := ignore.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode index filter: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+
+	return &fileFilter{
+		gitignore:   gitignore,
+		include:     include,
+		exclude:     exclude,
+		maxFileSize: filter.MaxFileSize,
+		fingerprint: hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// skipDir reports whether a directory, and so everything under it, is left
+// out
+func (f *fileFilter) skipDir(relPath string) bool {
+	return f.gitignore.Ignored(relPath, true) || f.exclude.Ignored(relPath, true)
+}
+
+// skipFile returns why a file is left out, or "" when it should be indexed
+func (f *fileFilter) skipFile(relPath string, size int64) string {
+	switch {
+	case f.gitignore.Ignored(relPath, false):
+		return "ignored by .gitignore"
+	case f.exclude.Ignored(relPath, false):
+		return "excluded"
+	// A file is included when it or one of its directories matches
+	case !f.include.Empty() && !f.include.Ignored(relPath, false):
+		return "not included"
+	case size > f.maxFileSize:
+		return fmt.Sprintf("larger than %d bytes", f.maxFileSize)
+	}
+	return ""
+}
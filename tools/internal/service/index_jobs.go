@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"mcpserver/internal/auth"
+	"mcpserver/internal/models"
+	"mcpserver/internal/storage"
+	"mcpserver/pkg/git"
+)
+
+// finishedJobRetention is how long completed, failed and cancelled jobs stay
+// queryable before they are pruned
+const finishedJobRetention = 24 * time.Hour
+
+var (
+	ErrJobNotFound     = errors.New("indexing job not found")
+	ErrJobNotRunning   = errors.New("indexing job is not running")
+	ErrAlreadyIndexing = errors.New("repository branch is already being indexed")
+)
+
+// indexProgress collects the counters of an indexing run. It is updated by
+// the walker and batcher while a job's status is read concurrently.
+type indexProgress struct {
+	mu     sync.Mutex
+	report models.IndexReport
+	// files and removed are the files this run indexed and dropped, for
+	// the catalog
+	files   map[string]storage.CatalogFile
+	removed []string
+}
+
+func (p *indexProgress) setMode(mode models.IndexMode) {
+	p.mu.Lock()
+	p.report.Mode = mode
+	p.mu.Unlock()
+}
+
+func (p *indexProgress) setCommit(sha string) {
+	p.mu.Lock()
+	p.report.Commit = sha
+	p.mu.Unlock()
+}
+
+// commit returns the commit being indexed, if the run indexes a Git clone
+func (p *indexProgress) commit() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.report.Commit
+}
+
+func (p *indexProgress) fileSeen() {
+	p.mu.Lock()
+	p.report.FilesSeen++
+	p.mu.Unlock()
+}
+
+// maxReportedSkips caps how many skipped files a report lists by name
+const maxReportedSkips = 100
+
+func (p *indexProgress) fileSkipped(relPath, reason string) {
+	p.mu.Lock()
+	p.fileSkippedLocked(relPath, reason)
+	p.mu.Unlock()
+}
+
+func (p *indexProgress) fileSkippedLocked(relPath, reason string) {
+	p.report.FilesSkipped++
+	if p.report.SkipReasons == nil {
+		p.report.SkipReasons = make(map[string]int)
+	}
+	p.report.SkipReasons[reason]++
+	if len(p.report.SkippedFiles) < maxReportedSkips {
+		p.report.SkippedFiles = append(p.report.SkippedFiles, models.SkippedFile{Path: relPath, Reason: reason})
+	}
+}
+
+func (p *indexProgress) fileProcessed() {
+	p.mu.Lock()
+	p.report.FilesProcessed++
+	p.mu.Unlock()
+}
+
+// fileIndexed records the language and chunk count of a file whose chunks
+// were queued
+func (p *indexProgress) fileIndexed(relPath, language string, chunks int) {
+	p.mu.Lock()
+	if p.files == nil {
+		p.files = make(map[string]storage.CatalogFile)
+	}
+	p.files[relPath] = storage.CatalogFile{Language: language, Chunks: chunks}
+	p.mu.Unlock()
+}
+
+// filesFailed takes back the files among relPaths that were counted as
+// processed and indexed, counting them as skipped for reason instead, so
+// neither the report nor the catalog claims their vectors were stored
+func (p *indexProgress) filesFailed(relPaths []string, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, relPath := range relPaths {
+		if _, ok := p.files[relPath]; !ok {
+			continue
+		}
+		delete(p.files, relPath)
+		p.report.FilesProcessed--
+		p.fileSkippedLocked(relPath, reason)
+	}
+}
+
+// fileRemoved records a file whose vectors were dropped from the index
+func (p *indexProgress) fileRemoved(relPath string) {
+	p.mu.Lock()
+	delete(p.files, relPath)
+	p.removed = append(p.removed, relPath)
+	p.mu.Unlock()
+}
+
+// catalogUpdate returns the files this run indexed and removed as an
+// update to the catalog
+func (p *indexProgress) catalogUpdate() storage.CatalogUpdate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	update := storage.CatalogUpdate{
+		Commit:  p.report.Commit,
+		Full:    p.report.Mode == models.IndexModeFull,
+		Files:   make(map[string]storage.CatalogFile, len(p.files)),
+		Removed: append([]string(nil), p.removed...),
+	}
+	for relPath, file := range p.files {
+		update.Files[relPath] = file
+	}
+	return update
+}
+
+func (p *indexProgress) fileDeleted() {
+	p.mu.Lock()
+	p.report.FilesDeleted++
+	p.mu.Unlock()
+}
+
+func (p *indexProgress) chunksStored(n int) {
+	p.mu.Lock()
+	p.report.ChunksStored += n
+	p.mu.Unlock()
+}
+
+func (p *indexProgress) addError(format string, args ...interface{}) {
+	p.mu.Lock()
+	p.report.Errors = append(p.report.Errors, fmt.Sprintf(format, args...))
+	p.mu.Unlock()
+}
+
+// snapshot returns a copy of the report that is safe to hand out
+func (p *indexProgress) snapshot() models.IndexReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	report := p.report
+	report.Errors = append([]string{}, p.report.Errors...)
+	report.SkippedFiles = append([]models.SkippedFile(nil), p.report.SkippedFiles...)
+	if p.report.SkipReasons != nil {
+		report.SkipReasons = make(map[string]int, len(p.report.SkipReasons))
+		for reason, n := range p.report.SkipReasons {
+			report.SkipReasons[reason] = n
+		}
+	}
+	return report
+}
+
+// indexRunFunc does the work of an indexing job
+type indexRunFunc func(ctx context.Context, progress *indexProgress) error
+
+// indexJob is the service-side state of a background indexing job
+type indexJob struct {
+	run indexRunFunc
+
+	mu       sync.Mutex
+	job      models.IndexJob
+	progress *indexProgress
+	cancel   context.CancelFunc
+}
+
+func (j *indexJob) snapshot() models.IndexJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job := j.job
+	job.Report = j.progress.snapshot()
+	return job
+}
+
+func (j *indexJob) finished() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.job.FinishedAt != nil
+}
+
+// StartIndexJob queues a background job indexing the branch of repoURL and
+// returns immediately with the job's initial state. The caller in ctx must be
+// allowed to index the repository branch; the job itself outlives ctx.
+func (ri *RepoIndexerService) StartIndexJob(ctx context.Context, repoURL, branch string, filter models.IndexFilter) (*models.IndexJob, error) {
+	repository := repositoryFromURL(repoURL)
+	if err := ri.policy.Authorize(ctx, auth.ActionIndex, repository, branch); err != nil {
+		return nil, err
+	}
+	if err := validateGitSource(repoURL, branch); err != nil {
+		return nil, err
+	}
+	if err := validateIndexFilter(filter); err != nil {
+		return nil, err
+	}
+
+	// The URL to clone may carry credentials, so only a redacted copy is
+	// reported
+	job := models.IndexJob{
+		Source:     models.IndexSourceGit,
+		RepoURL:    git.RedactURL(repoURL),
+		Repository: repository,
+		Branch:     branch,
+	}
+	return ri.startJob(job, func(ctx context.Context, progress *indexProgress) error {
+		return ri.indexRepository(ctx, repoURL, branch, filter, progress)
+	})
+}
+
+// startJob registers a job for the repository branch in info and runs it in
+// the background, unless that branch is already being indexed
+func (ri *RepoIndexerService) startJob(info models.IndexJob, run indexRunFunc) (*models.IndexJob, error) {
+	repository, branch := info.Repository, info.Branch
+
+	ri.jobsMu.Lock()
+	defer ri.jobsMu.Unlock()
+
+	ri.pruneJobsLocked()
+
+	for _, existing := range ri.jobs {
+		existing.mu.Lock()
+		busy := existing.job.Repository == repository && existing.job.Branch == branch && existing.job.FinishedAt == nil
+		existing.mu.Unlock()
+		if busy {
+			return nil, fmt.Errorf("%w: %s@%s", ErrAlreadyIndexing, repository, branch)
+		}
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	info.ID = id
+	info.Status = models.IndexJobQueued
+	info.CreatedAt = time.Now()
+
+	jobCtx, cancel := context.WithCancel(context.Background())
+	job := &indexJob{
+		run:      run,
+		job:      info,
+		progress: &indexProgress{},
+		cancel:   cancel,
+	}
+	ri.jobs[id] = job
+
+	go ri.runIndexJob(jobCtx, job)
+
+	snapshot := job.snapshot()
+	return &snapshot, nil
+}
+
+// GetIndexJob returns the current state of a job. The caller in ctx must be
+// allowed to read the job's repository branch.
+func (ri *RepoIndexerService) GetIndexJob(ctx context.Context, id string) (*models.IndexJob, error) {
+	ri.jobsMu.Lock()
+	job, ok := ri.jobs[id]
+	ri.jobsMu.Unlock()
+
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	snapshot := job.snapshot()
+	if err := ri.policy.Authorize(ctx, auth.ActionRead, snapshot.Repository, snapshot.Branch); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// ListIndexJobs returns every retained job the caller in ctx may read, newest
+// first
+func (ri *RepoIndexerService) ListIndexJobs(ctx context.Context) []models.IndexJob {
+	ri.jobsMu.Lock()
+	jobs := make([]models.IndexJob, 0, len(ri.jobs))
+	for _, job := range ri.jobs {
+		snapshot := job.snapshot()
+		if ri.policy.Allowed(ctx, auth.ActionRead, snapshot.Repository, snapshot.Branch) {
+			jobs = append(jobs, snapshot)
+		}
+	}
+	ri.jobsMu.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// CancelIndexJob cancels a queued or running job. The job stops at the next
+// file boundary and ends in the cancelled state. The caller in ctx must be
+// allowed to index the job's repository branch.
+func (ri *RepoIndexerService) CancelIndexJob(ctx context.Context, id string) (*models.IndexJob, error) {
+	ri.jobsMu.Lock()
+	job, ok := ri.jobs[id]
+	ri.jobsMu.Unlock()
+
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	job.mu.Lock()
+	repository, branch := job.job.Repository, job.job.Branch
+	job.mu.Unlock()
+	if err := ri.policy.Authorize(ctx, auth.ActionIndex, repository, branch); err != nil {
+		return nil, err
+	}
+
+	if job.finished() {
+		return nil, ErrJobNotRunning
+	}
+
+	fmt.Printf("Cancelling indexing job %s\n", id)
+	job.cancel()
+
+	snapshot := job.snapshot()
+	return &snapshot, nil
+}
+
+func (ri *RepoIndexerService) runIndexJob(ctx context.Context, job *indexJob) {
+	defer job.cancel()
+
+	job.mu.Lock()
+	started := time.Now()
+	job.job.Status = models.IndexJobRunning
+	job.job.StartedAt = &started
+	id, source, repository, branch := job.job.ID, job.job.Source, job.job.Repository, job.job.Branch
+	job.mu.Unlock()
+
+	fmt.Printf("Indexing job %s started for %s@%s from %s\n", id, repository, branch, source)
+
+	err := job.run(ctx, job.progress)
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	finished := time.Now()
+	job.job.FinishedAt = &finished
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// A cancelled clone surfaces as a killed process rather than ctx.Err()
+		job.job.Status = models.IndexJobCancelled
+	case err != nil:
+		job.job.Status = models.IndexJobFailed
+		job.job.Error = err.Error()
+	default:
+		job.job.Status = models.IndexJobCompleted
+	}
+
+	fmt.Printf("Indexing job %s finished with status %s in %s\n", job.job.ID, job.job.Status, finished.Sub(started))
+}
+
+// pruneJobsLocked drops finished jobs past their retention period. The caller
+// must hold jobsMu.
+func (ri *RepoIndexerService) pruneJobsLocked() {
+	cutoff := time.Now().Add(-finishedJobRetention)
+	for id, job := range ri.jobs {
+		job.mu.Lock()
+		expired := job.job.FinishedAt != nil && job.job.FinishedAt.Before(cutoff)
+		job.mu.Unlock()
+		if expired {
+			delete(ri.jobs, id)
+		}
+	}
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
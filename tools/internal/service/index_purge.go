@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"mcpserver/internal/auth"
+	"mcpserver/internal/models"
+	"mcpserver/internal/storage"
+)
+
+var (
+	ErrPurgePathsNeedBranch = errors.New("paths can only be purged from a branch")
+	ErrInvalidPurgePath     = errors.New("paths must be relative to the repository root")
+)
+
+// PurgeIndex removes the vectors of a repository, of one of its branches, or
+// of files of a branch, and reports how many were removed. Purging a
+// repository or branch also forgets what it was indexed at, so indexing it
+// again is a full index. The caller in ctx must be allowed to index the
+// branch, or every branch when purging the whole repository.
+func (ri *RepoIndexerService) PurgeIndex(ctx context.Context, req models.PurgeRequest) (*models.PurgeResult, error) {
+	if err := validateRepositoryName(req.Repository); err != nil {
+		return nil, err
+	}
+	if len(req.Paths) > 0 && req.Branch == "" {
+		return nil, ErrPurgePathsNeedBranch
+	}
+	paths, err := cleanPurgePaths(req.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ri.policy.Authorize(ctx, auth.ActionIndex, req.Repository, req.Branch); err != nil {
+		return nil, err
+	}
+
+	// A running job would store chunks again right after they are deleted
+	if err := ri.checkNotIndexing(req.Repository, req.Branch); err != nil {
+		return nil, err
+	}
+
+	deleted := 0
+	if len(paths) == 0 && req.Branch == "" {
+		// Stores that select vectors by ID prefix only find the branches
+		// whose prefix was hashed for length one branch at a time
+		for _, branch := range ri.knownBranches(req.Repository) {
+			n, err := ri.vectorStore.DeleteByFilter(storage.DeleteFilter{Repository: req.Repository, Branch: branch})
+			deleted += n
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete vectors of %s@%s: %w", req.Repository, branch, err)
+			}
+		}
+	}
+
+	n, err := ri.vectorStore.DeleteByFilter(storage.DeleteFilter{
+		Repository: req.Repository,
+		Branch:     req.Branch,
+		Paths:      paths,
+	})
+	deleted += n
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete vectors: %w", err)
+	}
+
+	switch {
+	case len(paths) > 0:
+		// The rest of the branch is still indexed at its commit
+		err = ri.catalog.RemoveFiles(req.Repository, req.Branch, paths)
+	case req.Branch != "":
+		if err = ri.indexState.ClearIndexedState(req.Repository, req.Branch); err == nil {
+			err = ri.catalog.RemoveBranch(req.Repository, req.Branch)
+		}
+	default:
+		if err = ri.indexState.ClearRepository(req.Repository); err == nil {
+			err = ri.catalog.RemoveRepository(req.Repository)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear index state: %w", err)
+	}
+
+	fmt.Printf("Purged %d vectors from %s\n", deleted, purgeTarget(req.Repository, req.Branch, paths))
+
+	return &models.PurgeResult{
+		Repository: req.Repository,
+		Branch:     req.Branch,
+		Paths:      paths,
+		Deleted:    deleted,
+	}, nil
+}
+
+// knownBranches lists the branches of repository in the catalog or the index
+// state, sorted
+func (ri *RepoIndexerService) knownBranches(repository string) []string {
+	seen := make(map[string]bool)
+	var branches []string
+	for _, branch := range append(ri.catalog.Branches(repository), ri.indexState.Branches(repository)...) {
+		if !seen[branch] {
+			seen[branch] = true
+			branches = append(branches, branch)
+		}
+	}
+	sort.Strings(branches)
+	return branches
+}
+
+// checkNotIndexing returns ErrAlreadyIndexing when a job is indexing the
+// branch of repository, or any of its branches when branch is empty
+func (ri *RepoIndexerService) checkNotIndexing(repository, branch string) error {
+	ri.jobsMu.Lock()
+	defer ri.jobsMu.Unlock()
+
+	for _, existing := range ri.jobs {
+		existing.mu.Lock()
+		busy := existing.job.Repository == repository && (branch == "" || existing.job.Branch == branch) && existing.job.FinishedAt == nil
+		jobBranch := existing.job.Branch
+		existing.mu.Unlock()
+		if busy {
+			return fmt.Errorf("%w: %s@%s", ErrAlreadyIndexing, repository, jobBranch)
+		}
+	}
+	return nil
+}
+
+// cleanPurgePaths normalises file paths to the slash-separated form they are
+// indexed under
+func cleanPurgePaths(paths []string) ([]string, error) {
+	cleaned := make([]string, 0, len(paths))
+	for _, p := range paths {
+		p = path.Clean(strings.TrimPrefix(p, "/"))
+		if p == "." || p == ".." || strings.HasPrefix(p, "../") {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPurgePath, p)
+		}
+		cleaned = append(cleaned, p)
+	}
+	return cleaned, nil
+}
+
+func purgeTarget(repository, branch string, paths []string) string {
+	switch {
+	case len(paths) > 0:
+		return fmt.Sprintf("%d files of %s@%s", len(paths), repository, branch)
+	case branch != "":
+		return repository + "@" + branch
+	default:
+		return "every branch of " + repository
+	}
+}
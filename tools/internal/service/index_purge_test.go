@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"mcpserver/internal/models"
+	"mcpserver/internal/storage"
+)
+
+// prefixStore deletes by ID prefix alone, as Pinecone does
+type prefixStore struct {
+	*storage.MemoryStore
+}
+
+func (s *prefixStore) DeleteByFilter(filter storage.DeleteFilter) (int, error) {
+	prefix := filter.Repository + "@"
+	if filter.Branch != "" {
+		prefix = storage.BranchVectorIDPrefix(filter.Repository, filter.Branch)
+	}
+	ids, err := s.List(prefix)
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), s.Delete(ids)
+}
+
+func TestPurgeRepositoryWithHashedBranch(t *testing.T) {
+	longBranch := "feature/" + strings.Repeat("x", 300)
+	if strings.HasPrefix(storage.BranchVectorIDPrefix("acme/api", longBranch), "acme/api@") {
+		t.Fatal("branch prefix is not hashed")
+	}
+
+	store := &prefixStore{MemoryStore: storage.NewMemoryStore()}
+	indexState, err := storage.NewIndexStateStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := storage.NewCatalogStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, branch := range []string{"main", longBranch} {
+		if err := store.StoreBatch([]models.CodeChunk{
+			{Repository: "acme/api", Branch: branch, FilePath: "a.go", Embedding: []float32{1}},
+			{Repository: "acme/api", Branch: branch, FilePath: "b.go", Embedding: []float32{1}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// One branch is only in the catalog, the other only in the index state
+	if err := catalog.Update("acme/api", "main", storage.CatalogUpdate{Full: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexState.SetIndexedState("acme/api", longBranch, storage.IndexedState{Commit: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ri := NewRepoIndexerService(store, storage.NewHashingEmbedder(8), indexState, catalog, nil, nil, IndexerOptions{})
+	result, err := ri.PurgeIndex(context.Background(), models.PurgeRequest{Repository: "acme/api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Deleted != 4 {
+		t.Errorf("Deleted = %d, want 4", result.Deleted)
+	}
+	if ids, _ := store.List(""); len(ids) != 0 {
+		t.Errorf("vectors left after purge: %q", ids)
+	}
+	if branches := indexState.Branches("acme/api"); len(branches) != 0 {
+		t.Errorf("index state still holds %q", branches)
+	}
+	if branches := catalog.Branches("acme/api"); len(branches) != 0 {
+		t.Errorf("catalog still holds %q", branches)
+	}
+}
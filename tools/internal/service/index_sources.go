@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mcpserver/internal/auth"
+	"mcpserver/internal/models"
+	"mcpserver/pkg/archive"
+)
+
+var (
+	ErrPathNotAllowed     = errors.New("path is not under an allowed index root")
+	ErrInvalidRepository  = errors.New("repository name is required and may not contain '@', ':', '#' or whitespace")
+	ErrInvalidBranch      = errors.New("branch is required and may not contain '@', ':', '#' or whitespace")
+	ErrLocalPathsDisabled = errors.New("indexing local paths is disabled: no allowed roots are configured")
+)
+
+// StartPathIndexJob queues a background job indexing the directory at path as
+// the branch of repository. The path must lie under one of the allowed roots
+// and the caller in ctx must be allowed to index the repository branch.
+func (ri *RepoIndexerService) StartPathIndexJob(ctx context.Context, path, repository, branch string, filter models.IndexFilter) (*models.IndexJob, error) {
+	if err := validateRepositoryName(repository); err != nil {
+		return nil, err
+	}
+	if err := validateBranchName(branch); err != nil {
+		return nil, err
+	}
+	if err := ri.policy.Authorize(ctx, auth.ActionIndex, repository, branch); err != nil {
+		return nil, err
+	}
+	if err := validateIndexFilter(filter); err != nil {
+		return nil, err
+	}
+
+	dir, err := ri.resolveAllowedPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	job := models.IndexJob{
+		Source:     models.IndexSourcePath,
+		Path:       dir,
+		Repository: repository,
+		Branch:     branch,
+	}
+	return ri.startJob(job, func(ctx context.Context, progress *indexProgress) error {
+		return ri.indexLocalDirectory(ctx, dir, repository, branch, models.IndexSourcePath, filter, progress)
+	})
+}
+
+// StartArchiveIndexJob queues a background job indexing the tar.gz or zip
+// archive at archivePath as the branch of repository. The job takes ownership
+// of the archive file and removes it when done, including when the job cannot
+// be started. The caller in ctx must be allowed to index the repository branch.
+func (ri *RepoIndexerService) StartArchiveIndexJob(ctx context.Context, archivePath, repository, branch string, filter models.IndexFilter) (*models.IndexJob, error) {
+	started := false
+	defer func() {
+		if !started {
+			os.Remove(archivePath)
+		}
+	}()
+
+	if err := validateRepositoryName(repository); err != nil {
+		return nil, err
+	}
+	if err := validateBranchName(branch); err != nil {
+		return nil, err
+	}
+	if err := ri.policy.Authorize(ctx, auth.ActionIndex, repository, branch); err != nil {
+		return nil, err
+	}
+	if err := validateIndexFilter(filter); err != nil {
+		return nil, err
+	}
+
+	job := models.IndexJob{
+		Source:     models.IndexSourceArchive,
+		Repository: repository,
+		Branch:     branch,
+	}
+	snapshot, err := ri.startJob(job, func(ctx context.Context, progress *indexProgress) error {
+		defer os.Remove(archivePath)
+		return ri.indexArchive(ctx, archivePath, repository, branch, filter, progress)
+	})
+	started = err == nil
+	return snapshot, err
+}
+
+func (ri *RepoIndexerService) indexArchive(ctx context.Context, archivePath, repository, branch string, filter models.IndexFilter, progress *indexProgress) error {
+	tempDir, err := ioutil.TempDir("", "archive-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dir, err := archive.Extract(archivePath, tempDir, ri.options.ArchiveLimits)
+	if err != nil {
+		return fmt.Errorf("failed to extract archive: %w", err)
+	}
+
+	fmt.Printf("Extracted archive for %s@%s to: %s\n", repository, branch, dir)
+
+	return ri.indexLocalDirectory(ctx, dir, repository, branch, models.IndexSourceArchive, filter, progress)
+}
+
+// indexLocalDirectory fully indexes a directory that is not a Git clone.
+// There is no commit to diff against later, so any commit recorded by an
+// earlier Git index of the branch is forgotten.
+func (ri *RepoIndexerService) indexLocalDirectory(ctx context.Context, dir, repository, branch string, source models.IndexSource, request models.IndexFilter, progress *indexProgress) error {
+	fmt.Printf("Indexing directory: %s as %s, branch: %s\n", dir, repository, branch)
+
+	if err := ri.indexState.ClearIndexedState(repository, branch); err != nil {
+		return fmt.Errorf("failed to reset index state: %w", err)
+	}
+
+	filter, err := newFileFilter(dir, ri.effectiveFilter(repository, request))
+	if err != nil {
+		return err
+	}
+
+	progress.setMode(models.IndexModeFull)
+	if err := ri.processDirectory(ctx, dir, repository, branch, filter, progress); err != nil {
+		return err
+	}
+	return ri.recordCatalog(repository, branch, source, progress)
+}
+
+// resolveAllowedPath returns the absolute, symlink-free form of path, which
+// must be a directory under one of the allowed roots
+func (ri *RepoIndexerService) resolveAllowedPath(path string) (string, error) {
+	if len(ri.options.AllowedRoots) == 0 {
+		return "", ErrLocalPathsDisabled
+	}
+
+	dir, err := resolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %s: %w", path, err)
+	}
+
+	for _, root := range ri.options.AllowedRoots {
+		resolvedRoot, err := resolvePath(root)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(resolvedRoot, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			info, err := os.Stat(dir)
+			if err != nil {
+				return "", fmt.Errorf("invalid path %s: %w", path, err)
+			}
+			if !info.IsDir() {
+				return "", fmt.Errorf("invalid path %s: not a directory", path)
+			}
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrPathNotAllowed, path)
+}
+
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// validateRepositoryName rejects names that would break the
+// "repository@branch:path#index" vector ID scheme
+func validateRepositoryName(repository string) error {
+	if repository == "" || strings.ContainsAny(repository, "@:# \t\r\n") {
+		return ErrInvalidRepository
+	}
+	return nil
+}
+
+// validateBranchName rejects branches that would break the vector ID scheme.
+// A branch such as "a:b" would otherwise list under the ID prefix of branch
+// "a". Git sources are checked by git.ValidateRef instead.
+func validateBranchName(branch string) error {
+	if branch == "" || strings.ContainsAny(branch, "@:# \t\r\n") {
+		return ErrInvalidBranch
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mcpserver/internal/models"
+	"mcpserver/internal/storage"
+)
+
+func TestStartLocalIndexJobValidatesBranch(t *testing.T) {
+	root := t.TempDir()
+	ri := NewRepoIndexerService(storage.NewMemoryStore(), storage.NewHashingEmbedder(8), nil, nil, nil, nil, IndexerOptions{AllowedRoots: []string{root}})
+
+	tests := []struct {
+		branch string
+		want   error
+	}{
+		// "acme/api@a:b:" lists under the ID prefix of branch "a"
+		{"a:b", ErrInvalidBranch},
+		{"a@b", ErrInvalidBranch},
+		{"a#b", ErrInvalidBranch},
+		{"a b", ErrInvalidBranch},
+		{"", ErrInvalidBranch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			if _, err := ri.StartPathIndexJob(context.Background(), root, "acme/api", tt.branch, models.IndexFilter{}); !errors.Is(err, tt.want) {
+				t.Errorf("StartPathIndexJob() error = %v, want %v", err, tt.want)
+			}
+
+			archivePath := filepath.Join(t.TempDir(), "upload.tar.gz")
+			if err := os.WriteFile(archivePath, []byte("archive"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ri.StartArchiveIndexJob(context.Background(), archivePath, "acme/api", tt.branch, models.IndexFilter{}); !errors.Is(err, tt.want) {
+				t.Errorf("StartArchiveIndexJob() error = %v, want %v", err, tt.want)
+			}
+			if _, err := os.Stat(archivePath); !os.IsNotExist(err) {
+				t.Error("rejected archive was not removed")
+			}
+		})
+	}
+}
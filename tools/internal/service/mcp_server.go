@@ -1,25 +1,37 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 
+	"mcpserver/internal/auth"
 	"mcpserver/internal/models"
 	"mcpserver/internal/storage"
 )
 
+// ErrCredentialsDisabled is returned by the GitHub credential methods when no
+// credential key is configured
+var ErrCredentialsDisabled = errors.New("credential storage is disabled; set CREDENTIALS_KEY to enable it")
+
+// ErrInvalidCredential is wrapped by the errors ConfigureGitHub returns for
+// credentials that are missing or malformed
+var ErrInvalidCredential = errors.New("invalid credential")
+
 type MCPServerService struct {
-	pineconeStore  *storage.PineconeStore
-	openaiClient   *storage.OpenAIClient
-	vectorSearch   *VectorSearchService
-	repoIndexer    *RepoIndexerService
+	vectorSearch *VectorSearchService
+	repoIndexer  *RepoIndexerService
+	credentials  *storage.CredentialStore
+	policy       *auth.Policy
 }
 
-func NewMCPServerService(pineconeStore *storage.PineconeStore, openaiClient *storage.OpenAIClient) *MCPServerService {
+func NewMCPServerService(vectorSearch *VectorSearchService, repoIndexer *RepoIndexerService, credentials *storage.CredentialStore, policy *auth.Policy) *MCPServerService {
 	return &MCPServerService{
-		pineconeStore: pineconeStore,
-		openaiClient:  openaiClient,
-		vectorSearch:  NewVectorSearchService(pineconeStore, openaiClient),
-		repoIndexer:   NewRepoIndexerService(pineconeStore, openaiClient),
+		vectorSearch: vectorSearch,
+		repoIndexer:  repoIndexer,
+		credentials:  credentials,
+		policy:       policy,
 	}
 }
 
@@ -34,6 +46,7 @@ func (mcp *MCPServerService) GetServerInfo() *models.ServerInfo {
 			"repository_indexing",
 		},
 		Endpoints: map[string]string{
+			"mcp":              "/mcp",
 			"vector_search":    "/vector-search",
 			"index_repository": "/index-repository",
 			"health":           "/health",
@@ -41,7 +54,7 @@ func (mcp *MCPServerService) GetServerInfo() *models.ServerInfo {
 	}
 }
 
-func (mcp *MCPServerService) HandleCursorAction(action string, data map[string]interface{}) (interface{}, error) {
+func (mcp *MCPServerService) HandleCursorAction(ctx context.Context, action string, data map[string]interface{}) (interface{}, error) {
 	switch action {
 	case "connect":
 		return map[string]string{"status": "connected"}, nil
@@ -58,23 +71,29 @@ func (mcp *MCPServerService) HandleCursorAction(action string, data map[string]i
 			req.Limit = limit
 		}
 
-		return mcp.vectorSearch.Search(req)
+		return mcp.vectorSearch.Search(ctx, req)
 	default:
 		return nil, fmt.Errorf("unknown cursor action: %s", action)
 	}
 }
 
-func (mcp *MCPServerService) HandleChat(message, repository string, context map[string]interface{}) (interface{}, error) {
+// HandleChat answers a question with code from the repository. The caller in
+// ctx must be allowed to read it.
+func (mcp *MCPServerService) HandleChat(ctx context.Context, message, repository string, context map[string]interface{}) (interface{}, error) {
 	// First, search for relevant code using vector search
 	searchRequest := &models.SearchRequest{
 		Query:      message,
 		Repository: repository,
 		Limit:      5,
 	}
+	// "documentation" in the context lets callers prefer or exclude docs
+	if mode, ok := context["documentation"].(string); ok {
+		searchRequest.Documentation = models.DocumentationMode(mode)
+	}
 
-	searchResult, err := mcp.vectorSearch.Search(searchRequest)
+	searchResult, err := mcp.vectorSearch.Search(ctx, searchRequest)
 	if err != nil {
-		return nil, fmt.Errorf("search failed: %v", err)
+		return nil, fmt.Errorf("search failed: %w", err)
 	}
 
 	// Format response with search results
@@ -84,8 +103,69 @@ func (mcp *MCPServerService) HandleChat(message, repository string, context map[
 	}, nil
 }
 
-func (mcp *MCPServerService) ConfigureGitHub(repository, token string) error {
-	// Store GitHub configuration (implement secure storage)
-	// This is a placeholder for actual implementation
+// ConfigureGitHub stores the access token and SSH deploy key used to clone a
+// private repository, replacing any stored before; either may be empty.
+// repository may be "owner/name" or a clone URL. Credentials serve every
+// branch, so the caller in ctx must be allowed to index all of them.
+func (mcp *MCPServerService) ConfigureGitHub(ctx context.Context, repository, token, sshKey string) error {
+	if mcp.credentials == nil {
+		return ErrCredentialsDisabled
+	}
+
+	repository = repositoryFromURL(repository)
+	if err := mcp.policy.Authorize(ctx, auth.ActionIndex, repository, "*"); err != nil {
+		return err
+	}
+
+	token = strings.TrimSpace(token)
+	sshKey = strings.TrimSpace(sshKey)
+	if token == "" && sshKey == "" {
+		return fmt.Errorf("%w: token or SSH key is required", ErrInvalidCredential)
+	}
+	if sshKey != "" && !strings.Contains(sshKey, "PRIVATE KEY-----") {
+		return fmt.Errorf("%w: SSH key must be a PEM-encoded private key", ErrInvalidCredential)
+	}
+
+	creds := storage.RepositoryCredentials{Token: token, SSHKey: sshKey}
+	if err := mcp.credentials.Set(repository, creds); err != nil {
+		return err
+	}
+
+	fmt.Printf("Stored GitHub credentials for %s\n", repository)
+	return nil
+}
+
+// ListGitHubCredentials describes the stored credentials for repositories the
+// caller in ctx may index. The secrets themselves are never returned.
+func (mcp *MCPServerService) ListGitHubCredentials(ctx context.Context) ([]models.GitHubCredential, error) {
+	if mcp.credentials == nil {
+		return nil, ErrCredentialsDisabled
+	}
+
+	credentials := []models.GitHubCredential{}
+	for _, credential := range mcp.credentials.List() {
+		if mcp.policy.Allowed(ctx, auth.ActionIndex, credential.Repository, "*") {
+			credentials = append(credentials, credential)
+		}
+	}
+	return credentials, nil
+}
+
+// RevokeGitHub deletes the stored credentials for a repository
+func (mcp *MCPServerService) RevokeGitHub(ctx context.Context, repository string) error {
+	if mcp.credentials == nil {
+		return ErrCredentialsDisabled
+	}
+
+	repository = repositoryFromURL(repository)
+	if err := mcp.policy.Authorize(ctx, auth.ActionIndex, repository, "*"); err != nil {
+		return err
+	}
+
+	if err := mcp.credentials.Delete(repository); err != nil {
+		return err
+	}
+
+	fmt.Printf("Revoked GitHub credentials for %s\n", repository)
 	return nil
-}
\ No newline at end of file
+}
@@ -1,39 +1,108 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 
+	"mcpserver/internal/auth"
 	"mcpserver/internal/models"
 	"mcpserver/internal/storage"
+	"mcpserver/pkg/archive"
+	"mcpserver/pkg/chunker"
 	"mcpserver/pkg/git"
+	"mcpserver/pkg/ignore"
+	"mcpserver/pkg/language"
+	"mcpserver/pkg/tokenizer"
 	"mcpserver/pkg/utils"
 )
 
+// IndexerOptions tunes how RepoIndexerService talks to the embedder and the
+// vector store
+type IndexerOptions struct {
+	// EmbeddingBatchSize is the number of chunks sent per embedding request
+	EmbeddingBatchSize int
+	// UpsertBatchSize is the number of vectors sent per upsert request
+	UpsertBatchSize int
+	// CredentialHosts are the Git hosts stored credentials may be sent to.
+	// Credentials are keyed by owner/name alone, so without this a URL on
+	// another host could collect them.
+	CredentialHosts []string
+	// AllowedRoots are the server directories under which local paths may
+	// be indexed. Without any, path indexing is disabled.
+	AllowedRoots []string
+	// ArchiveLimits bound what an uploaded archive may expand to
+	ArchiveLimits archive.Limits
+	// Filter applies to every repository
+	Filter models.IndexFilter
+	// RepositoryFilters add to Filter for repositories matching their key,
+	// as loaded by LoadIndexRules
+	RepositoryFilters map[string]models.IndexFilter
+	// ChunkOverlap is the number of lines each chunk repeats from the one
+	// before it
+	ChunkOverlap int
+	// ChunkSizing is "tokens" to size chunks by estimated token count, or
+	// "bytes", the default, to size them by length
+	ChunkSizing string
+	// ChunkMaxTokens is the token budget of a chunk in "tokens" sizing. It
+	// is lowered to fit the embedding model's input limit.
+	ChunkMaxTokens int
+	// ChunkMaxBytes is the size of a chunk in "bytes" sizing
+	ChunkMaxBytes int
+}
+
 type RepoIndexerService struct {
-	pineconeStore *storage.PineconeStore
-	openaiClient  *storage.OpenAIClient
+	vectorStore storage.VectorStore
+	embedder    storage.Embedder
+	indexState  *storage.IndexStateStore
+	catalog     *storage.CatalogStore
+	credentials *storage.CredentialStore
+	options     IndexerOptions
+	policy      *auth.Policy
+
+	jobsMu sync.Mutex
+	jobs   map[string]*indexJob
 }
 
-func NewRepoIndexerService(pineconeStore *storage.PineconeStore, openaiClient *storage.OpenAIClient) *RepoIndexerService {
+func NewRepoIndexerService(vectorStore storage.VectorStore, embedder storage.Embedder, indexState *storage.IndexStateStore, catalog *storage.CatalogStore, credentials *storage.CredentialStore, policy *auth.Policy, options IndexerOptions) *RepoIndexerService {
+	if options.EmbeddingBatchSize <= 0 {
+		options.EmbeddingBatchSize = 64
+	}
+	if options.UpsertBatchSize <= 0 {
+		options.UpsertBatchSize = 100
+	}
+	if len(options.CredentialHosts) == 0 {
+		options.CredentialHosts = []string{"github.com"}
+	}
+	if options.ChunkMaxTokens <= 0 {
+		options.ChunkMaxTokens = 512
+	}
+	if options.ChunkMaxBytes <= 0 {
+		options.ChunkMaxBytes = 1000
+	}
+
 	return &RepoIndexerService{
-		pineconeStore: pineconeStore,
-		openaiClient:  openaiClient,
+		vectorStore: vectorStore,
+		embedder:    embedder,
+		indexState:  indexState,
+		catalog:     catalog,
+		credentials: credentials,
+		options:     options,
+		policy:      policy,
+		jobs:        make(map[string]*indexJob),
 	}
 }
 
-func (ri *RepoIndexerService) IndexRepository(repoURL, branch string) error {
-	// Extract repository name from URL
-	parts := strings.Split(repoURL, "/")
-	repoName := parts[len(parts)-1]
-	if strings.HasSuffix(repoName, ".git") {
-		repoName = repoName[:len(repoName)-4]
-	}
+func (ri *RepoIndexerService) indexRepository(ctx context.Context, repoURL, branch string, request models.IndexFilter, progress *indexProgress) error {
+	repository := repositoryFromURL(repoURL)
 
-	fmt.Printf("Indexing repository: %s, branch: %s\n", repoURL, branch)
+	fmt.Printf("Indexing repository: %s, branch: %s\n", git.RedactURL(repoURL), branch)
 
 	// Create temporary directory for cloning
 	tempDir, err := ioutil.TempDir("", "repo-")
@@ -44,167 +113,575 @@ func (ri *RepoIndexerService) IndexRepository(repoURL, branch string) error {
 
 	fmt.Printf("Created temp directory: %s\n", tempDir)
 
-	// Clone repository using git package
-	if err := git.CloneRepository(repoURL, tempDir, branch); err != nil {
+	creds, err := ri.cloneCredentials(repoURL, repository)
+	if err != nil {
+		return err
+	}
+
+	// Clone exactly the requested branch, tag or commit
+	head, err := git.CloneRepository(ctx, repoURL, tempDir, branch, creds)
+	if err != nil {
 		return fmt.Errorf("failed to clone repository: %w", err)
 	}
+	progress.setCommit(head)
 
 	fmt.Printf("Cloned repository to: %s\n", tempDir)
 
-	// Process repository files
-	return ri.processDirectory(tempDir, repoURL, branch)
+	// Vectors written before chunk-level IDs were introduced can never be
+	// overwritten by a re-index, so drop them before storing new ones
+	if err := ri.purgeLegacyVectors(repository); err != nil {
+		return fmt.Errorf("failed to remove legacy vectors: %w", err)
+	}
+
+	filter, err := newFileFilter(tempDir, ri.effectiveFilter(repository, request))
+	if err != nil {
+		return err
+	}
+
+	// Process repository files, only the changed ones when possible
+	if changes, ok := ri.changesSinceLastIndex(ctx, tempDir, repository, branch, head, filter, creds); ok {
+		err = ri.processChanges(ctx, tempDir, repository, branch, changes, filter, progress)
+	} else {
+		progress.setMode(models.IndexModeFull)
+		err = ri.processDirectory(ctx, tempDir, repository, branch, filter, progress)
+	}
+	if err != nil {
+		return err
+	}
+	if err := ri.recordCatalog(repository, branch, models.IndexSourceGit, progress); err != nil {
+		return err
+	}
+
+	// Files that failed this run would never be retried by an incremental
+	// index, so only remember the commit when everything went through
+	if report := progress.snapshot(); len(report.Errors) > 0 {
+		fmt.Printf("Not recording indexed commit for %s@%s: %d errors\n", repository, branch, len(report.Errors))
+		return nil
+	}
+	return ri.indexState.SetIndexedState(repository, branch, storage.IndexedState{
+		Commit:   head,
+		Filter:   filter.fingerprint,
+		Chunking: ri.chunkingFingerprint(),
+	})
 }
 
-func (ri *RepoIndexerService) processDirectory(dir, repoURL, branch string) error {
-	fileCount := 0
-	skippedCount := 0
-	processedCount := 0
+// changesSinceLastIndex diffs head against the commit the branch was last
+// indexed at. The clone is shallow, so the old commit is fetched first. It
+// returns false when a full index is needed instead: the branch was never
+// indexed, was indexed with another filter, chunk settings or embedding
+// model, is missing from the catalog, the old commit cannot be fetched, as
+// after a force push, or a .gitignore file changed.
+func (ri *RepoIndexerService) changesSinceLastIndex(ctx context.Context, repoDir, repository, branch, head string, filter *fileFilter, creds *git.Credentials) ([]git.FileChange, bool) {
+	state, ok := ri.indexState.IndexedState(repository, branch)
+	if !ok {
+		return nil, false
+	}
+	if state.Filter != filter.fingerprint {
+		fmt.Printf("Index filter of %s@%s changed, falling back to full index\n", repository, branch)
+		return nil, false
+	}
+	// Unchanged files would keep chunks cut the old way
+	if chunking := ri.chunkingFingerprint(); state.Chunking != chunking {
+		fmt.Printf("Chunk settings of %s@%s changed from %q to %q, falling back to full index\n", repository, branch, state.Chunking, chunking)
+		return nil, false
+	}
+	// The catalog only learns of a branch's files from a full index, and
+	// vectors of different models cannot be searched together
+	cataloged, ok := ri.catalog.Branch(repository, branch)
+	if !ok {
+		fmt.Printf("%s@%s is not in the catalog, falling back to full index\n", repository, branch)
+		return nil, false
+	}
+	if cataloged.EmbeddingModel != ri.embedder.Model() {
+		fmt.Printf("Embedding model of %s@%s changed from %s, falling back to full index\n", repository, branch, cataloged.EmbeddingModel)
+		return nil, false
+	}
+	indexed := state.Commit
+	if indexed == head {
+		return nil, true
+	}
+
+	if !git.HasCommit(ctx, repoDir, indexed) {
+		if err := git.FetchCommit(ctx, repoDir, indexed, creds); err != nil || !git.HasCommit(ctx, repoDir, indexed) {
+			fmt.Printf("Previously indexed commit %s not available, falling back to full index: %v\n", indexed, err)
+			return nil, false
+		}
+	}
 
-	baseDirLen := len(dir)
+	changes, err := git.DiffFiles(ctx, repoDir, indexed, head)
+	if err != nil {
+		fmt.Printf("Diff failed, falling back to full index: %v\n", err)
+		return nil, false
+	}
 
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			fmt.Printf("Error accessing path %s: %v\n", path, err)
-			return err
+	// Which files are ignored may have changed anywhere in the tree
+	for _, change := range changes {
+		if path.Base(change.Path) == ignore.GitignoreFile || path.Base(change.OldPath) == ignore.GitignoreFile {
+			fmt.Printf("%s changed, falling back to full index\n", ignore.GitignoreFile)
+			return nil, false
 		}
+	}
+
+	fmt.Printf("Incremental index from %s to %s: %d changed files\n", indexed, head, len(changes))
+	return changes, true
+}
+
+// processChanges re-indexes added, modified and renamed files and removes the
+// vectors of deleted and renamed-away files
+func (ri *RepoIndexerService) processChanges(ctx context.Context, dir, repository, branch string, changes []git.FileChange, filter *fileFilter, progress *indexProgress) error {
+	batcher := newChunkBatcher(ri.embedder, ri.vectorStore, ri.options.EmbeddingBatchSize, ri.options.UpsertBatchSize, progress)
 
-		fileCount++
-		relPath := path
-		if len(path) > baseDirLen {
-			relPath = path[baseDirLen+1:]
+	// Re-indexed files may have had more chunks before; those are dropped
+	// once the new ones are stored
+	type reindexedFile struct {
+		path   string
+		chunks int
+	}
+	var reindexed []reindexedFile
+
+	if len(changes) == 0 {
+		progress.setMode(models.IndexModeUnchanged)
+	} else {
+		progress.setMode(models.IndexModeIncremental)
+	}
+
+	for _, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 
-		// Skip directories and hidden files
-		if info.IsDir() {
-			if strings.HasPrefix(info.Name(), ".") {
-				if strings.Contains(path, ".git") {
-					fmt.Printf("Skipping .git directory: %s\n", relPath)
-					skippedCount++
-					return filepath.SkipDir // Skip .git directories entirely
-				}
-				fmt.Printf("Skipping hidden directory: %s\n", relPath)
-				skippedCount++
-				return nil
+		// Deleted files and the old side of renames lose all their vectors
+		stalePath := ""
+		switch change.Type {
+		case git.ChangeDeleted:
+			stalePath = change.Path
+		case git.ChangeRenamed:
+			stalePath = change.OldPath
+		}
+		if stalePath != "" {
+			if err := ri.deleteStaleChunks(repository, branch, stalePath, 0); err != nil {
+				progress.addError("failed to delete vectors of %s: %v", stalePath, err)
+			} else {
+				progress.fileDeleted()
+				progress.fileRemoved(stalePath)
 			}
-			return nil
+		}
+		if change.Type == git.ChangeDeleted {
+			continue
 		}
 
-		// Skip hidden files
-		if strings.HasPrefix(info.Name(), ".") {
-			fmt.Printf("Skipping hidden file: %s\n", relPath)
-			skippedCount++
-			return nil
+		path := filepath.Join(dir, filepath.FromSlash(change.Path))
+		info, err := os.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
 		}
 
-		// Skip binary files based on extension
-		if utils.IsBinaryFile(path) {
-			fmt.Printf("Skipping binary file: %s\n", relPath)
-			skippedCount++
-			return nil
+		// Mirror the directory walk, which never descends into hidden directories
+		if isInHiddenDirectory(change.Path) {
+			continue
+		}
+
+		progress.fileSeen()
+		chunks, indexed := ri.indexFile(batcher, progress, filter, path, change.Path, info, repository, branch)
+		if err := batcher.Err(); err != nil {
+			return err
+		}
+		if indexed {
+			reindexed = append(reindexed, reindexedFile{path: change.Path, chunks: chunks})
+		} else {
+			// A changed file that is now skipped must not keep its old vectors
+			if err := ri.deleteStaleChunks(repository, branch, change.Path, 0); err != nil {
+				progress.addError("failed to delete vectors of %s: %v", change.Path, err)
+			} else {
+				progress.fileRemoved(change.Path)
+			}
+		}
+	}
+
+	if err := batcher.Flush(); err != nil {
+		return err
+	}
+
+	for _, file := range reindexed {
+		if err := ri.deleteStaleChunks(repository, branch, file.path, file.chunks); err != nil {
+			progress.addError("failed to delete stale chunks of %s: %v", file.path, err)
 		}
+	}
+
+	report := progress.snapshot()
+	fmt.Printf("Incremental processing complete. Changed files: %d, Skipped: %d, Processed: %d, Deleted: %d, Chunks stored: %d\n",
+		len(changes), report.FilesSkipped, report.FilesProcessed, report.FilesDeleted, report.ChunksStored)
+
+	return nil
+}
 
-		// Read file content
-		content, err := ioutil.ReadFile(path)
+// processDirectory indexes every file under dir, then removes the vectors of
+// files that are no longer there or no longer indexable, and the chunks files
+// no longer have
+func (ri *RepoIndexerService) processDirectory(ctx context.Context, dir, repository, branch string, filter *fileFilter, progress *indexProgress) error {
+	batcher := newChunkBatcher(ri.embedder, ri.vectorStore, ri.options.EmbeddingBatchSize, ri.options.UpsertBatchSize, progress)
+
+	// Chunk counts of every file indexed by this run, by file vector ID prefix
+	indexed := make(map[string]int)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			fmt.Printf("Error reading file %s: %v\n", relPath, err)
-			skippedCount++
-			return nil // Skip files we can't read
+			fmt.Printf("Error accessing path %s: %v\n", path, err)
+			return err
 		}
 
-		// Skip large files and binary content
-		if len(content) > 100000 {
-			fmt.Printf("Skipping large file: %s (%d bytes)\n", relPath, len(content))
-			skippedCount++
-			return nil
+		// Stop between files once the job has been cancelled
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
 		}
+		relPath = filepath.ToSlash(relPath)
 
-		if utils.ContainsBinaryData(content) {
-			fmt.Printf("Skipping file with binary data: %s\n", relPath)
-			skippedCount++
+		// Skip hidden and ignored directories entirely
+		if info.IsDir() {
+			if relPath == "." {
+				return nil
+			}
+			if isHiddenDirectory(info.Name()) {
+				fmt.Printf("Skipping hidden directory: %s\n", relPath)
+				return filepath.SkipDir
+			}
+			if filter.skipDir(relPath) {
+				fmt.Printf("Skipping ignored directory: %s\n", relPath)
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
-		// Process file content
-		fmt.Printf("Processing file: %s\n", relPath)
-		if err := ri.processFile(string(content), path, repoURL, branch); err != nil {
-			fmt.Printf("Error processing file %s: %v\n", path, err)
-			skippedCount++
-			return nil // Continue with other files even if one fails
+		// Symlinks could point anywhere on the server, so only regular files
+		// are read
+		if !info.Mode().IsRegular() {
+			return nil
 		}
 
-		processedCount++
-		if processedCount%10 == 0 {
-			fmt.Printf("Processed %d files so far...\n", processedCount)
+		progress.fileSeen()
+		if chunks, ok := ri.indexFile(batcher, progress, filter, path, relPath, info, repository, branch); ok {
+			indexed[storage.FileVectorIDPrefix(repository, branch, relPath)] = chunks
 		}
 
-		return nil
+		// A failed batch leaves files without their vectors, so the run
+		// cannot complete
+		return batcher.Err()
 	})
 
-	fmt.Printf("Directory processing complete. Total files: %d, Skipped: %d, Processed: %d\n",
-		fileCount, skippedCount, processedCount)
+	// Embed and store whatever is left of the final batch
+	if err == nil {
+		err = batcher.Flush()
+	}
+
+	// Only a complete walk knows every file that is still there
+	if err == nil {
+		if sweepErr := ri.deleteStaleVectors(repository, branch, indexed, progress); sweepErr != nil {
+			progress.addError("%v", sweepErr)
+		}
+	}
+
+	report := progress.snapshot()
+	fmt.Printf("Directory processing complete. Total files: %d, Skipped: %d, Processed: %d, Chunks stored: %d\n",
+		report.FilesSeen, report.FilesSkipped, report.FilesProcessed, report.ChunksStored)
 
 	return err
 }
 
-func (ri *RepoIndexerService) processFile(content, filePath, repoURL, branch string) error {
-	// Extract repository name from URL
-	parts := strings.Split(repoURL, "/")
-	repoOwner := parts[len(parts)-2]
-	repoName := parts[len(parts)-1]
-	if strings.HasSuffix(repoName, ".git") {
-		repoName = repoName[:len(repoName)-4]
+// indexFile applies the per-file filters to a single file and queues its
+// chunks when it passes, reporting whether it did and how many chunks it
+// queued. Failures are recorded in progress, never returned, so one bad file
+// does not stop the run.
+func (ri *RepoIndexerService) indexFile(batcher *chunkBatcher, progress *indexProgress, filter *fileFilter, path, relPath string, info os.FileInfo, repository, branch string) (int, bool) {
+	// Skip hidden files
+	if strings.HasPrefix(info.Name(), ".") {
+		fmt.Printf("Skipping hidden file: %s\n", relPath)
+		progress.fileSkipped(relPath, "hidden file")
+		return 0, false
+	}
+
+	// Skip ignored, excluded and large files before reading them
+	if reason := filter.skipFile(relPath, info.Size()); reason != "" {
+		fmt.Printf("Skipping file %s: %s\n", relPath, reason)
+		progress.fileSkipped(relPath, reason)
+		return 0, false
+	}
+
+	// Skip binary files based on extension
+	if utils.IsBinaryFile(path) {
+		fmt.Printf("Skipping binary file: %s\n", relPath)
+		progress.fileSkipped(relPath, "binary file extension")
+		return 0, false
+	}
+
+	// Read file content
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Error reading file %s: %v\n", relPath, err)
+		progress.addError("failed to read %s: %v", relPath, err)
+		progress.fileSkipped(relPath, "unreadable")
+		return 0, false // Skip files we can't read
+	}
+
+	// Skip binary content, transcoding UTF-16 and dropping byte order marks
+	text, err := utils.DecodeText(content)
+	if err != nil {
+		fmt.Printf("Skipping file %s: %v\n", relPath, err)
+		progress.fileSkipped(relPath, err.Error())
+		return 0, false
+	}
+
+	// Process file content
+	fmt.Printf("Processing file: %s\n", relPath)
+	chunks, err := ri.processFile(batcher, progress, text, relPath, repository, branch)
+	if err != nil {
+		// The batcher reports a failed batch once for every file in it
+		if batcher.Err() != nil {
+			progress.fileSkipped(relPath, "storing chunks failed")
+			return 0, false
+		}
+		fmt.Printf("Error processing file %s: %v\n", path, err)
+		progress.addError("failed to process %s: %v", relPath, err)
+		progress.fileSkipped(relPath, "processing failed")
+		return 0, false // Continue with other files even if one fails
 	}
-	repository := fmt.Sprintf("%s/%s", repoOwner, repoName)
 
-	// Get relative file path - extract the path after the temp directory
-	relPath := filePath
-	tempDirMarker := "/repo-"
-	if idx := strings.LastIndex(filePath, tempDirMarker); idx != -1 {
-		// Find the next path separator after the temp dir name
-		nextSlash := strings.Index(filePath[idx+1:], "/")
-		if nextSlash != -1 {
-			relPath = filePath[idx+nextSlash+2:] // +2 to account for the slash and the idx+1 offset
+	progress.fileProcessed()
+	return chunks, true
+}
+
+// isHiddenDirectory reports whether a directory name starts with a dot, as
+// .git does. The directory walk never descends into one.
+func isHiddenDirectory(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+// isInHiddenDirectory reports whether any directory of a slash-separated
+// relative path is hidden
+func isInHiddenDirectory(relPath string) bool {
+	parts := strings.Split(relPath, "/")
+	for _, part := range parts[:len(parts)-1] {
+		if isHiddenDirectory(part) {
+			return true
 		}
 	}
+	return false
+}
 
-	fmt.Printf("Processing file %s with relative path %s\n", filePath, relPath)
+// processFile splits a file into chunks and queues them, returning how many it
+// queued. Chunks stored for a previous, longer version of the file are left
+// for the caller to remove once the new ones are stored.
+func (ri *RepoIndexerService) processFile(batcher *chunkBatcher, progress *indexProgress, content, relPath, repository, branch string) (int, error) {
+	commit := progress.commit()
 
-	// Determine language from file extension
-	language := utils.GetLanguageFromExtension(filepath.Ext(filePath))
+	// Determine language from the file name or its "#!" line
+	lang := language.Detect(relPath, content)
 
-	// Split content into chunks of approximately 1000 tokens
-	chunks := utils.SplitIntoChunks(content, 1000)
+	// Split content into chunks along declarations or document sections
+	chunks := chunker.Split(content, lang, ri.chunkOptions())
 	fmt.Printf("Split into %d chunks\n", len(chunks))
 
-	// Process each chunk
+	// Queue each chunk; the batcher embeds and stores them in bulk
+	codeChunks := make([]models.CodeChunk, len(chunks))
 	for i, chunk := range chunks {
-		// Get embedding for the chunk
-		embedding, err := ri.openaiClient.GetEmbedding(chunk)
-		if err != nil {
-			return fmt.Errorf("failed to get embedding: %w", err)
-		}
-
-		// Create code chunk
-		codeChunk := models.CodeChunk{
-			Content:    chunk,
+		codeChunks[i] = models.CodeChunk{
+			Content:    chunk.Content,
 			FilePath:   relPath,
 			Repository: repository,
 			Branch:     branch,
-			Language:   language,
-			Embedding:  embedding,
+			Commit:     commit,
+			Language:   lang,
+			ChunkIndex: i,
+			StartLine:  chunk.StartLine,
+			EndLine:    chunk.EndLine,
+			Symbol:     chunk.Symbol.Name,
+			SymbolKind: chunk.Symbol.Kind,
+			Headings:   chunk.Headings,
+
+			Documentation: language.IsDocumentation(lang),
+		}
+	}
+
+	if err := batcher.Add(codeChunks...); err != nil {
+		return 0, err
+	}
+
+	progress.fileIndexed(relPath, lang, len(chunks))
+	return len(chunks), nil
+}
+
+// chunkOptions sizes chunks by the configured measure, never past what the
+// embedding model accepts. The token count is an estimate, so a tenth of the
+// model's limit is kept in reserve.
+func (ri *RepoIndexerService) chunkOptions() chunker.Options {
+	opts := chunker.Options{
+		MaxSize: ri.options.ChunkMaxBytes,
+		Overlap: ri.options.ChunkOverlap,
+	}
+	if ri.options.ChunkSizing == "tokens" {
+		opts.MaxSize = ri.options.ChunkMaxTokens
+		opts.Measure = tokenizer.CountTokens
+	}
+
+	// A token always spans at least one byte, so the token limit bounds
+	// byte sizing too
+	if limit := ri.embedder.MaxInputTokens(); limit > 0 {
+		if budget := limit - limit/10; opts.MaxSize > budget {
+			opts.MaxSize = budget
 		}
+	}
+	return opts
+}
+
+// chunkingFingerprint describes the effective chunk settings, which decide
+// where every chunk of a file starts and ends
+func (ri *RepoIndexerService) chunkingFingerprint() string {
+	sizing := "bytes"
+	if ri.options.ChunkSizing == "tokens" {
+		sizing = "tokens"
+	}
+	opts := ri.chunkOptions()
+	return fmt.Sprintf("%s max=%d overlap=%d", sizing, opts.MaxSize, opts.Overlap)
+}
 
-		fmt.Printf("Storing chunk for %s, repository %s\n", relPath, repository)
+// deleteStaleChunks removes every stored chunk of a file whose index is at or
+// beyond keep
+func (ri *RepoIndexerService) deleteStaleChunks(repository, branch, relPath string, keep int) error {
+	prefix := storage.FileVectorIDPrefix(repository, branch, relPath)
+	ids, err := ri.vectorStore.List(prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list chunks: %w", err)
+	}
 
-		// Store in vector database
-		if err := ri.pineconeStore.Store(codeChunk); err != nil {
-			return fmt.Errorf("failed to store chunk: %w", err)
+	var stale []string
+	for _, id := range ids {
+		if index, ok := storage.VectorChunkIndex(id, prefix); ok && index >= keep {
+			stale = append(stale, id)
 		}
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+
+	fmt.Printf("Deleting %d stale chunks for %s\n", len(stale), relPath)
+	if err := ri.vectorStore.Delete(stale); err != nil {
+		return fmt.Errorf("failed to delete stale chunks: %w", err)
+	}
+	return nil
+}
 
-		if i == 0 || i%10 == 0 {
-			fmt.Printf("Indexed chunk %d for file: %s\n", i, relPath)
+// deleteStaleVectors removes the vectors of every file of a repository branch
+// whose ID prefix is not in indexed, as after a full walk that no longer
+// found them, and the chunks of indexed files beyond their current count. It
+// lists the branch once rather than once per file.
+func (ri *RepoIndexerService) deleteStaleVectors(repository, branch string, indexed map[string]int, progress *indexProgress) error {
+	ids, err := ri.vectorStore.List(storage.BranchVectorIDPrefix(repository, branch))
+	if err != nil {
+		return fmt.Errorf("failed to list vectors: %w", err)
+	}
+
+	var stale []string
+	files := make(map[string]bool)
+	for _, id := range ids {
+		filePrefix := id[:strings.LastIndex(id, "#")+1]
+		chunks, ok := indexed[filePrefix]
+		if !ok {
+			stale = append(stale, id)
+			files[filePrefix] = true
+			continue
+		}
+		if index, ok := storage.VectorChunkIndex(id, filePrefix); ok && index >= chunks {
+			stale = append(stale, id)
 		}
 	}
 
+	if len(stale) == 0 {
+		return nil
+	}
+
+	fmt.Printf("Deleting %d stale vectors, %d files no longer in %s@%s\n", len(stale), len(files), repository, branch)
+	if err := ri.vectorStore.Delete(stale); err != nil {
+		return fmt.Errorf("failed to delete stale vectors: %w", err)
+	}
+	for range files {
+		progress.fileDeleted()
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+// purgeLegacyVectors deletes vectors stored under the old "repository-filePath"
+// ID scheme for a repository
+func (ri *RepoIndexerService) purgeLegacyVectors(repository string) error {
+	deleted, err := ri.vectorStore.DeleteByFilter(storage.DeleteFilter{Repository: repository, Legacy: true})
+	if deleted > 0 {
+		fmt.Printf("Deleted %d legacy vectors for repository %s\n", deleted, repository)
+	}
+	return err
+}
+
+// cloneCredentials returns the stored secrets for a private repository that
+// suit the remote's transport: the token for HTTPS and the deploy key for
+// SSH. It returns nil when the repository has none, or the remote is not on
+// a trusted credential host.
+func (ri *RepoIndexerService) cloneCredentials(repoURL, repository string) (*git.Credentials, error) {
+	stored, err := ri.credentials.Get(repository)
+	if errors.Is(err, storage.ErrCredentialNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	transport, host := git.RemoteHost(repoURL)
+	trusted := false
+	for _, allowed := range ri.options.CredentialHosts {
+		if strings.EqualFold(host, allowed) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		fmt.Printf("Not using stored credentials for %s: %s is not a credential host\n", repository, host)
+		return nil, nil
+	}
+
+	var creds git.Credentials
+	switch transport {
+	case "https":
+		creds.Token = stored.Token
+	case "ssh":
+		creds.SSHKey = stored.SSHKey
+	}
+	if creds == (git.Credentials{}) {
+		fmt.Printf("No stored credentials for %s suit %s remotes\n", repository, transport)
+		return nil, nil
+	}
+
+	fmt.Printf("Using stored credentials to clone %s over %s\n", repository, transport)
+	return &creds, nil
+}
+
+// repositoryFromURL extracts the "owner/name" repository identifier from a
+// clone URL, including scp-like SSH remotes such as git@github.com:owner/name.git
+func repositoryFromURL(repoURL string) string {
+	parts := strings.Split(strings.Trim(git.RemotePath(repoURL), "/"), "/")
+	repoName := strings.TrimSuffix(parts[len(parts)-1], ".git")
+	if len(parts) < 2 {
+		return repoName
+	}
+	return fmt.Sprintf("%s/%s", parts[len(parts)-2], repoName)
+}
+
+// validateGitSource rejects remotes and refs git could take for options or
+// local paths before any job is started for them
+func validateGitSource(repoURL, branch string) error {
+	if err := git.ValidateRemote(repoURL); err != nil {
+		return err
+	}
+	return git.ValidateRef(branch)
+}
@@ -1,38 +1,80 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 
+	"mcpserver/internal/auth"
 	"mcpserver/internal/models"
 	"mcpserver/internal/storage"
+	"mcpserver/pkg/language"
+)
+
+var (
+	// ErrUnknownLanguage is returned when a search filters by a language
+	// that is not in the language registry
+	ErrUnknownLanguage = errors.New("unknown language")
+	// ErrInvalidDocumentationMode is returned for a documentation search
+	// mode other than include, exclude, only or prefer
+	ErrInvalidDocumentationMode = errors.New("documentation must be include, exclude, only or prefer")
 )
 
 type VectorSearchService struct {
-	pineconeStore *storage.PineconeStore
-	openaiClient  *storage.OpenAIClient
+	vectorStore  storage.VectorStore
+	embedder     storage.Embedder
+	openaiClient *storage.OpenAIClient
+	policy       *auth.Policy
 }
 
-func NewVectorSearchService(pineconeStore *storage.PineconeStore, openaiClient *storage.OpenAIClient) *VectorSearchService {
+func NewVectorSearchService(vectorStore storage.VectorStore, embedder storage.Embedder, openaiClient *storage.OpenAIClient, policy *auth.Policy) *VectorSearchService {
 	return &VectorSearchService{
-		pineconeStore: pineconeStore,
-		openaiClient:  openaiClient,
+		vectorStore:  vectorStore,
+		embedder:     embedder,
+		openaiClient: openaiClient,
+		policy:       policy,
 	}
 }
 
-func (vs *VectorSearchService) Search(req *models.SearchRequest) (*models.SearchResponse, error) {
-	// Get query embedding
-	embedding, err := vs.openaiClient.GetEmbedding(req.Query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get query embedding: %v", err)
-	}
-
+// Search returns the chunks closest to the query. The caller in ctx must be
+// allowed to read the repository branch.
+func (vs *VectorSearchService) Search(ctx context.Context, req *models.SearchRequest) (*models.SearchResponse, error) {
 	// Set default branch if not provided
 	branch := req.Branch
 	if branch == "" {
 		branch = "main"
 	}
 
+	if err := vs.policy.Authorize(ctx, auth.ActionRead, req.Repository, branch); err != nil {
+		return nil, err
+	}
+
+	languages, err := canonicalLanguages(req.Languages)
+	if err != nil {
+		return nil, err
+	}
+	filter := storage.SearchFilter{Repository: req.Repository, Branch: branch, Languages: languages}
+
+	mode := req.Documentation
+	if mode == "" {
+		mode = models.DocumentationInclude
+	}
+	switch mode {
+	case models.DocumentationInclude, models.DocumentationPrefer:
+	case models.DocumentationExclude, models.DocumentationOnly:
+		isDocumentation := mode == models.DocumentationOnly
+		filter.Documentation = &isDocumentation
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDocumentationMode, mode)
+	}
+
+	// Get query embedding
+	embedding, err := vs.embedder.GetEmbedding(req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query embedding: %v", err)
+	}
+
 	// Set default limit if not provided
 	limit := req.Limit
 	if limit <= 0 {
@@ -40,7 +82,12 @@ func (vs *VectorSearchService) Search(req *models.SearchRequest) (*models.Search
 	}
 
 	// Search vector store with branch filter
-	chunks, err := vs.pineconeStore.Search(embedding, req.Repository, branch, limit)
+	var chunks []models.CodeChunk
+	if mode == models.DocumentationPrefer {
+		chunks, err = vs.searchDocumentationFirst(embedding, filter, limit)
+	} else {
+		chunks, err = vs.vectorStore.Search(embedding, filter, limit)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("vector store search failed: %v", err)
 	}
@@ -52,9 +99,41 @@ func (vs *VectorSearchService) Search(req *models.SearchRequest) (*models.Search
 	}, nil
 }
 
-func (vs *VectorSearchService) SearchWithSummary(req *models.SearchRequest) (map[string]interface{}, error) {
+// searchDocumentationFirst returns the documentation chunks closest to the
+// query followed by the closest code, limit chunks in all
+func (vs *VectorSearchService) searchDocumentationFirst(embedding []float32, filter storage.SearchFilter, limit int) ([]models.CodeChunk, error) {
+	isDocumentation := true
+	filter.Documentation = &isDocumentation
+	chunks, err := vs.vectorStore.Search(embedding, filter, limit)
+	if err != nil || len(chunks) >= limit {
+		return chunks, err
+	}
+
+	isDocumentation = false
+	code, err := vs.vectorStore.Search(embedding, filter, limit-len(chunks))
+	if err != nil {
+		return nil, err
+	}
+	return append(chunks, code...), nil
+}
+
+// canonicalLanguages maps language names, matched case-insensitively, to the
+// names stored with indexed chunks
+func canonicalLanguages(names []string) ([]string, error) {
+	languages := make([]string, 0, len(names))
+	for _, name := range names {
+		canonical, ok := language.Canonical(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownLanguage, name)
+		}
+		languages = append(languages, canonical)
+	}
+	return languages, nil
+}
+
+func (vs *VectorSearchService) SearchWithSummary(ctx context.Context, req *models.SearchRequest) (map[string]interface{}, error) {
 	// Perform regular search
-	searchResponse, err := vs.Search(req)
+	searchResponse, err := vs.Search(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -68,6 +147,10 @@ func (vs *VectorSearchService) SearchWithSummary(req *models.SearchRequest) (map
 			"repository": chunk.Repository,
 			"branch":     chunk.Branch,
 			"language":   chunk.Language,
+			"startLine":  chunk.StartLine,
+			"endLine":    chunk.EndLine,
+			"symbol":     chunk.Symbol,
+			"headings":   chunk.Headings,
 		}
 	}
 
@@ -81,4 +164,4 @@ func (vs *VectorSearchService) SearchWithSummary(req *models.SearchRequest) (map
 	return map[string]interface{}{
 		"summary": summary,
 	}, nil
-}
\ No newline at end of file
+}
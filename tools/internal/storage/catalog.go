@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"mcpserver/internal/models"
+)
+
+// CatalogFile is what the catalog remembers of an indexed file
+type CatalogFile struct {
+	Language string `json:"language"`
+	Chunks   int    `json:"chunks"`
+}
+
+// CatalogUpdate records an indexing run of a repository branch
+type CatalogUpdate struct {
+	Source         models.IndexSource
+	Commit         string
+	EmbeddingModel string
+	IndexedAt      time.Time
+	// Full replaces every file of the branch with Files. Otherwise Files
+	// are added or replaced and Removed files dropped.
+	Full    bool
+	Files   map[string]CatalogFile
+	Removed []string
+}
+
+// catalogEntry is the stored state of one repository branch
+type catalogEntry struct {
+	Repository     string                 `json:"repository"`
+	Branch         string                 `json:"branch"`
+	Source         models.IndexSource     `json:"source"`
+	Commit         string                 `json:"commit,omitempty"`
+	EmbeddingModel string                 `json:"embeddingModel"`
+	IndexedAt      time.Time              `json:"indexedAt"`
+	Files          map[string]CatalogFile `json:"files"`
+}
+
+func (e *catalogEntry) summary() models.IndexedBranch {
+	branch := models.IndexedBranch{
+		Branch:         e.Branch,
+		Source:         e.Source,
+		Commit:         e.Commit,
+		Files:          len(e.Files),
+		Languages:      make(map[string]int),
+		EmbeddingModel: e.EmbeddingModel,
+		IndexedAt:      e.IndexedAt,
+	}
+	for _, file := range e.Files {
+		branch.Chunks += file.Chunks
+		branch.Languages[file.Language]++
+	}
+	return branch
+}
+
+// CatalogStore keeps a catalog of the indexed repository branches and the
+// files in each, so clients can discover what can be searched. It is kept in
+// a single JSON file; an empty path keeps it in memory only.
+type CatalogStore struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]*catalogEntry
+}
+
+func NewCatalogStore(path string) (*CatalogStore, error) {
+	s := &CatalogStore{
+		path:    path,
+		entries: make(map[string]*catalogEntry),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog: %w", err)
+	}
+
+	return s, nil
+}
+
+// Branch returns the catalog entry of a repository branch
+func (s *CatalogStore) Branch(repository, branch string) (models.IndexedBranch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[catalogKey(repository, branch)]
+	if !ok {
+		return models.IndexedBranch{}, false
+	}
+	return entry.summary(), true
+}
+
+// Update records an indexing run of a repository branch
+func (s *CatalogStore) Update(repository, branch string, update CatalogUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := catalogKey(repository, branch)
+	entry, ok := s.entries[key]
+	if !ok || update.Full {
+		entry = &catalogEntry{
+			Repository: repository,
+			Branch:     branch,
+			Files:      make(map[string]CatalogFile),
+		}
+		s.entries[key] = entry
+	}
+
+	entry.Source = update.Source
+	entry.Commit = update.Commit
+	entry.EmbeddingModel = update.EmbeddingModel
+	entry.IndexedAt = update.IndexedAt
+	for _, removed := range update.Removed {
+		delete(entry.Files, removed)
+	}
+	for filePath, file := range update.Files {
+		entry.Files[filePath] = file
+	}
+
+	return s.saveLocked()
+}
+
+// Branches lists the cataloged branches of a repository
+func (s *CatalogStore) Branches(repository string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var branches []string
+	for _, entry := range s.entries {
+		if entry.Repository == repository {
+			branches = append(branches, entry.Branch)
+		}
+	}
+	return branches
+}
+
+// RemoveFiles drops files from a repository branch
+func (s *CatalogStore) RemoveFiles(repository, branch string, paths []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[catalogKey(repository, branch)]
+	if !ok {
+		return nil
+	}
+	for _, filePath := range paths {
+		delete(entry.Files, filePath)
+	}
+	return s.saveLocked()
+}
+
+// RemoveBranch drops a repository branch
+func (s *CatalogStore) RemoveBranch(repository, branch string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, catalogKey(repository, branch))
+	return s.saveLocked()
+}
+
+// RemoveRepository drops every branch of a repository
+func (s *CatalogStore) RemoveRepository(repository string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.entries {
+		if entry.Repository == repository {
+			delete(s.entries, key)
+		}
+	}
+	return s.saveLocked()
+}
+
+// Repositories lists the indexed repositories sorted by name, each with the
+// branches for which include returns true. Repositories left without
+// branches are omitted.
+func (s *CatalogStore) Repositories(include func(repository, branch string) bool) []models.IndexedRepository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byName := make(map[string]*models.IndexedRepository)
+	for _, entry := range s.entries {
+		if !include(entry.Repository, entry.Branch) {
+			continue
+		}
+		repo, ok := byName[entry.Repository]
+		if !ok {
+			repo = &models.IndexedRepository{Repository: entry.Repository}
+			byName[entry.Repository] = repo
+		}
+		repo.Branches = append(repo.Branches, entry.summary())
+	}
+
+	repositories := make([]models.IndexedRepository, 0, len(byName))
+	for _, repo := range byName {
+		sort.Slice(repo.Branches, func(i, j int) bool {
+			return repo.Branches[i].Branch < repo.Branches[j].Branch
+		})
+		repositories = append(repositories, *repo)
+	}
+	sort.Slice(repositories, func(i, j int) bool {
+		return repositories[i].Repository < repositories[j].Repository
+	})
+	return repositories
+}
+
+func (s *CatalogStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode catalog: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create catalog directory: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a torn file
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to replace catalog: %w", err)
+	}
+	return nil
+}
+
+func catalogKey(repository, branch string) string {
+	return repository + "@" + branch
+}
@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"mcpserver/internal/models"
+)
+
+// ErrCredentialNotFound is returned when no credentials are stored for a
+// repository
+var ErrCredentialNotFound = errors.New("no credentials stored for repository")
+
+// credentialKeySize is the AES-256 key length
+const credentialKeySize = 32
+
+// RepositoryCredentials are the secrets used to clone one repository. Either
+// may be empty.
+type RepositoryCredentials struct {
+	Token  string
+	SSHKey string
+}
+
+// storedCredential is the on-disk form of a repository's secrets. Each is
+// sealed with AES-GCM using the repository name and field as additional data,
+// so a value cannot be moved to another repository or field without failing
+// to decrypt.
+type storedCredential struct {
+	Ciphertext       string    `json:"ciphertext,omitempty"`
+	SSHKeyCiphertext string    `json:"sshKeyCiphertext,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// CredentialStore keeps access tokens and SSH deploy keys for private
+// repositories encrypted at rest in a single JSON file. An empty path keeps
+// them in memory only.
+type CredentialStore struct {
+	mu          sync.Mutex
+	path        string
+	aead        cipher.AEAD
+	credentials map[string]storedCredential
+}
+
+// ParseCredentialKey decodes a base64-encoded 32-byte key, as generated by
+// `openssl rand -base64 32`
+func ParseCredentialKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("credential key is not valid base64: %w", err)
+	}
+	if len(key) != credentialKeySize {
+		return nil, fmt.Errorf("credential key must be %d bytes, got %d", credentialKeySize, len(key))
+	}
+	return key, nil
+}
+
+func NewCredentialStore(path string, key []byte) (*CredentialStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize credential cipher: %w", err)
+	}
+
+	s := &CredentialStore{
+		path:        path,
+		aead:        aead,
+		credentials: make(map[string]storedCredential),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.credentials); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials: %w", err)
+	}
+
+	// Fail at startup rather than at the first clone when the key changed
+	for repository := range s.credentials {
+		if _, err := s.decryptLocked(repository); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Set stores or replaces the credentials for a repository
+func (s *CredentialStore) Set(repository string, creds RepositoryCredentials) error {
+	token, err := s.seal(repository, tokenField, creds.Token)
+	if err != nil {
+		return err
+	}
+	sshKey, err := s.seal(repository, sshKeyField, creds.SSHKey)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	credential := storedCredential{
+		Ciphertext:       token,
+		SSHKeyCiphertext: sshKey,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if existing, ok := s.credentials[repository]; ok {
+		credential.CreatedAt = existing.CreatedAt
+	}
+	s.credentials[repository] = credential
+	return s.saveLocked()
+}
+
+// Get returns the decrypted credentials for a repository. A nil store has no
+// credentials, so callers can use it unconditionally.
+func (s *CredentialStore) Get(repository string) (*RepositoryCredentials, error) {
+	if s == nil {
+		return nil, ErrCredentialNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.decryptLocked(repository)
+}
+
+// List describes the stored credentials without revealing the secrets
+func (s *CredentialStore) List() []models.GitHubCredential {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	credentials := make([]models.GitHubCredential, 0, len(s.credentials))
+	for repository, credential := range s.credentials {
+		credentials = append(credentials, models.GitHubCredential{
+			Repository: repository,
+			HasToken:   credential.Ciphertext != "",
+			HasSSHKey:  credential.SSHKeyCiphertext != "",
+			CreatedAt:  credential.CreatedAt,
+			UpdatedAt:  credential.UpdatedAt,
+		})
+	}
+	sort.Slice(credentials, func(i, j int) bool {
+		return credentials[i].Repository < credentials[j].Repository
+	})
+	return credentials
+}
+
+// Delete revokes the credentials for a repository
+func (s *CredentialStore) Delete(repository string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.credentials[repository]; !ok {
+		return ErrCredentialNotFound
+	}
+	delete(s.credentials, repository)
+	return s.saveLocked()
+}
+
+func (s *CredentialStore) decryptLocked(repository string) (*RepositoryCredentials, error) {
+	credential, ok := s.credentials[repository]
+	if !ok {
+		return nil, ErrCredentialNotFound
+	}
+
+	token, err := s.open(repository, tokenField, credential.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	sshKey, err := s.open(repository, sshKeyField, credential.SSHKeyCiphertext)
+	if err != nil {
+		return nil, err
+	}
+	return &RepositoryCredentials{Token: token, SSHKey: sshKey}, nil
+}
+
+// Fields of a stored credential, bound into its ciphertext
+const (
+	tokenField  = ""
+	sshKeyField = "#ssh-key"
+)
+
+func (s *CredentialStore) seal(repository, field, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(plaintext), []byte(repository+field))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *CredentialStore) open(repository, field, encoded string) (string, error) {
+	if encoded == "" {
+		return "", nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return "", fmt.Errorf("credential for %s is corrupt", repository)
+	}
+
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, []byte(repository+field))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt credential for %s; was the credential key changed?", repository)
+	}
+	return string(plaintext), nil
+}
+
+func (s *CredentialStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.credentials, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a torn file;
+	// even encrypted, the file is only readable by the server's user
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to replace credentials: %w", err)
+	}
+	return nil
+}
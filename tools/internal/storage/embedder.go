@@ -0,0 +1,18 @@
+package storage
+
+// Embedder turns text into embedding vectors
+type Embedder interface {
+	// GetEmbedding embeds a single text
+	GetEmbedding(text string) ([]float32, error)
+	// GetEmbeddings embeds several texts in one request, returning the
+	// embeddings in input order
+	GetEmbeddings(texts []string) ([][]float32, error)
+	// MaxInputTokens is the most tokens the model accepts per text, or zero
+	// when there is no limit
+	MaxInputTokens() int
+	// Model names the embedding model, so vectors from different models
+	// can be told apart
+	Model() string
+}
+
+var _ Embedder = (*OpenAIClient)(nil)
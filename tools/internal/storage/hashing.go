@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var _ Embedder = (*HashingEmbedder)(nil)
+
+// HashingEmbedder is a deterministic embedder that needs no model or network.
+// Each word is hashed into one of a fixed number of buckets (the "hashing
+// trick"), so texts sharing vocabulary land close together. It is meant for
+// offline tests and smoke runs, not for real semantic search.
+type HashingEmbedder struct {
+	dimensions int
+}
+
+func NewHashingEmbedder(dimensions int) *HashingEmbedder {
+	if dimensions <= 0 {
+		dimensions = 256
+	}
+	return &HashingEmbedder{dimensions: dimensions}
+}
+
+// MaxInputTokens reports no limit; any text can be hashed
+func (he *HashingEmbedder) MaxInputTokens() int {
+	return 0
+}
+
+func (he *HashingEmbedder) Model() string {
+	return "hashing-" + strconv.Itoa(he.dimensions)
+}
+
+func (he *HashingEmbedder) GetEmbeddings(texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i], _ = he.GetEmbedding(text)
+	}
+	return embeddings, nil
+}
+
+func (he *HashingEmbedder) GetEmbedding(text string) ([]float32, error) {
+	embedding := make([]float32, he.dimensions)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+
+		// The low bits pick the bucket and the top bit the sign, which keeps
+		// collisions from always adding up
+		bucket := int(sum % uint64(he.dimensions))
+		if sum>>63 == 1 {
+			embedding[bucket]--
+		} else {
+			embedding[bucket]++
+		}
+	}
+
+	var norm float64
+	for _, v := range embedding {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range embedding {
+			embedding[i] *= scale
+		}
+	}
+
+	return embedding, nil
+}
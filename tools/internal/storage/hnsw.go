@@ -0,0 +1,313 @@
+package storage
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// hnswIndex is an in-memory Hierarchical Navigable Small World graph used for
+// approximate nearest neighbour search by LocalStore. Vectors are normalised
+// on insert so distance is 1 - dot product (cosine distance). Removed vectors
+// stay in the graph as tombstones to keep it connected and are skipped in
+// results; the owning store rebuilds the index when tombstones pile up.
+type hnswIndex struct {
+	m              int
+	efConstruction int
+	efSearch       int
+	levelMult      float64
+	rng            *rand.Rand
+
+	nodes    []*hnswNode
+	byID     map[string]int
+	entry    int
+	maxLevel int
+	deleted  int
+}
+
+type hnswNode struct {
+	id        string
+	vector    []float32
+	neighbors [][]int
+	deleted   bool
+}
+
+type hnswCandidate struct {
+	node     int
+	distance float32
+}
+
+func newHNSWIndex(m, efConstruction, efSearch int) *hnswIndex {
+	if m < 2 {
+		m = 16
+	}
+	if efConstruction < m {
+		efConstruction = 200
+	}
+	if efSearch <= 0 {
+		efSearch = 64
+	}
+	return &hnswIndex{
+		m:              m,
+		efConstruction: efConstruction,
+		efSearch:       efSearch,
+		levelMult:      1 / math.Log(float64(m)),
+		rng:            rand.New(rand.NewSource(1)),
+		byID:           make(map[string]int),
+		entry:          -1,
+	}
+}
+
+// Len returns the number of live vectors in the index
+func (h *hnswIndex) Len() int {
+	return len(h.byID)
+}
+
+// Tombstones returns the number of removed vectors still held in the graph
+func (h *hnswIndex) Tombstones() int {
+	return h.deleted
+}
+
+// Insert adds a vector under id, replacing any previous vector with that id
+func (h *hnswIndex) Insert(id string, vector []float32) {
+	h.Remove(id)
+
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+	node := &hnswNode{
+		id:        id,
+		vector:    normalize(vector),
+		neighbors: make([][]int, level+1),
+	}
+	idx := len(h.nodes)
+	h.nodes = append(h.nodes, node)
+	h.byID[id] = idx
+
+	if h.entry == -1 {
+		h.entry = idx
+		h.maxLevel = level
+		return
+	}
+
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.greedyClosest(node.vector, ep, l)
+	}
+
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(node.vector, ep, h.efConstruction, l)
+		neighbors := h.selectNeighbors(candidates, h.maxNeighbors(l))
+		node.neighbors[l] = neighbors
+
+		for _, n := range neighbors {
+			h.link(n, idx, l)
+		}
+		ep = candidates[0].node
+	}
+
+	if level > h.maxLevel {
+		h.entry = idx
+		h.maxLevel = level
+	}
+}
+
+// Remove tombstones the vector stored under id, if any
+func (h *hnswIndex) Remove(id string) {
+	idx, ok := h.byID[id]
+	if !ok {
+		return
+	}
+	h.nodes[idx].deleted = true
+	delete(h.byID, id)
+	h.deleted++
+}
+
+// Search returns up to k live vector IDs closest to query that satisfy
+// accept, closest first, along with their cosine similarity
+func (h *hnswIndex) Search(query []float32, k int, accept func(id string) bool) ([]string, []float64) {
+	if h.entry == -1 || k <= 0 {
+		return nil, nil
+	}
+
+	q := normalize(query)
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.greedyClosest(q, ep, l)
+	}
+
+	candidates := h.searchLayer(q, ep, max(h.efSearch, k), 0)
+
+	var ids []string
+	var scores []float64
+	for _, c := range candidates {
+		node := h.nodes[c.node]
+		if node.deleted || (accept != nil && !accept(node.id)) {
+			continue
+		}
+		ids = append(ids, node.id)
+		scores = append(scores, 1-float64(c.distance))
+		if len(ids) == k {
+			break
+		}
+	}
+	return ids, scores
+}
+
+func (h *hnswIndex) maxNeighbors(level int) int {
+	if level == 0 {
+		return h.m * 2
+	}
+	return h.m
+}
+
+// link adds a directed edge from -> to on level, pruning from's neighbour
+// list back to the level's limit when it overflows
+func (h *hnswIndex) link(from, to, level int) {
+	node := h.nodes[from]
+	node.neighbors[level] = append(node.neighbors[level], to)
+
+	limit := h.maxNeighbors(level)
+	if len(node.neighbors[level]) <= limit {
+		return
+	}
+
+	candidates := make([]hnswCandidate, len(node.neighbors[level]))
+	for i, n := range node.neighbors[level] {
+		candidates[i] = hnswCandidate{node: n, distance: h.distance(node.vector, n)}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+	node.neighbors[level] = h.selectNeighbors(candidates, limit)
+}
+
+// selectNeighbors keeps the closest limit candidates, which must already be
+// sorted by ascending distance
+func (h *hnswIndex) selectNeighbors(candidates []hnswCandidate, limit int) []int {
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	neighbors := make([]int, len(candidates))
+	for i, c := range candidates {
+		neighbors[i] = c.node
+	}
+	return neighbors
+}
+
+// greedyClosest walks level from ep towards query and returns the closest
+// node it reaches
+func (h *hnswIndex) greedyClosest(query []float32, ep, level int) int {
+	best := ep
+	bestDist := h.distance(query, ep)
+	for changed := true; changed; {
+		changed = false
+		for _, n := range h.neighborsAt(best, level) {
+			if d := h.distance(query, n); d < bestDist {
+				best, bestDist = n, d
+				changed = true
+			}
+		}
+	}
+	return best
+}
+
+// searchLayer performs a best-first search of level starting from ep and
+// returns up to ef nodes sorted by ascending distance to query
+func (h *hnswIndex) searchLayer(query []float32, ep, ef, level int) []hnswCandidate {
+	visited := map[int]bool{ep: true}
+	start := hnswCandidate{node: ep, distance: h.distance(query, ep)}
+
+	candidates := &candidateHeap{items: []hnswCandidate{start}}
+	results := &candidateHeap{items: []hnswCandidate{start}, farthestFirst: true}
+
+	for candidates.Len() > 0 {
+		current := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && current.distance > results.items[0].distance {
+			break
+		}
+
+		for _, n := range h.neighborsAt(current.node, level) {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+
+			d := h.distance(query, n)
+			if results.Len() < ef || d < results.items[0].distance {
+				heap.Push(candidates, hnswCandidate{node: n, distance: d})
+				heap.Push(results, hnswCandidate{node: n, distance: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	sorted := append([]hnswCandidate(nil), results.items...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].distance < sorted[j].distance
+	})
+	return sorted
+}
+
+func (h *hnswIndex) neighborsAt(node, level int) []int {
+	neighbors := h.nodes[node].neighbors
+	if level >= len(neighbors) {
+		return nil
+	}
+	return neighbors[level]
+}
+
+func (h *hnswIndex) distance(query []float32, node int) float32 {
+	vector := h.nodes[node].vector
+	if len(vector) != len(query) {
+		return 2
+	}
+	var dot float32
+	for i := range query {
+		dot += query[i] * vector[i]
+	}
+	return 1 - dot
+}
+
+func normalize(vector []float32) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	out := make([]float32, len(vector))
+	if norm == 0 {
+		return out
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i, v := range vector {
+		out[i] = v * scale
+	}
+	return out
+}
+
+// candidateHeap is a binary heap of candidates ordered closest first, or
+// farthest first when farthestFirst is set
+type candidateHeap struct {
+	items         []hnswCandidate
+	farthestFirst bool
+}
+
+func (c *candidateHeap) Len() int { return len(c.items) }
+
+func (c *candidateHeap) Less(i, j int) bool {
+	if c.farthestFirst {
+		return c.items[i].distance > c.items[j].distance
+	}
+	return c.items[i].distance < c.items[j].distance
+}
+
+func (c *candidateHeap) Swap(i, j int) { c.items[i], c.items[j] = c.items[j], c.items[i] }
+
+func (c *candidateHeap) Push(x any) { c.items = append(c.items, x.(hnswCandidate)) }
+
+func (c *candidateHeap) Pop() any {
+	last := c.items[len(c.items)-1]
+	c.items = c.items[:len(c.items)-1]
+	return last
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func randomVectors(n, dimensions int, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = make([]float32, dimensions)
+		for j := range vectors[i] {
+			vectors[i][j] = rng.Float32()*2 - 1
+		}
+	}
+	return vectors
+}
+
+// exactNeighbors returns the IDs of the k vectors most similar to query
+func exactNeighbors(vectors map[string][]float32, query []float32, k int) []string {
+	ids := make([]string, 0, len(vectors))
+	for id := range vectors {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return cosineSimilarity(query, vectors[ids[i]]) > cosineSimilarity(query, vectors[ids[j]])
+	})
+	return ids[:min(k, len(ids))]
+}
+
+func TestHNSWRecall(t *testing.T) {
+	const k = 10
+
+	index := newHNSWIndex(16, 200, 64)
+	vectors := make(map[string][]float32)
+	for i, vector := range randomVectors(1000, 16, 1) {
+		id := strconv.Itoa(i)
+		vectors[id] = vector
+		index.Insert(id, vector)
+	}
+
+	found, total := 0, 0
+	for _, query := range randomVectors(50, 16, 2) {
+		ids, scores := index.Search(query, k, nil)
+		if len(ids) != k {
+			t.Fatalf("Search() returned %d results, want %d", len(ids), k)
+		}
+		for i := 1; i < len(scores); i++ {
+			if scores[i] > scores[i-1]+1e-6 {
+				t.Fatalf("Search() results not sorted by similarity: %v", scores)
+			}
+		}
+
+		got := make(map[string]bool)
+		for _, id := range ids {
+			got[id] = true
+		}
+		for _, id := range exactNeighbors(vectors, query, k) {
+			total++
+			if got[id] {
+				found++
+			}
+		}
+	}
+
+	if recall := float64(found) / float64(total); recall < 0.9 {
+		t.Errorf("recall@%d = %.2f, want at least 0.9", k, recall)
+	}
+}
+
+func TestHNSWRemoveAndReplace(t *testing.T) {
+	index := newHNSWIndex(16, 200, 64)
+	vectors := randomVectors(200, 8, 3)
+	for i, vector := range vectors {
+		index.Insert(strconv.Itoa(i), vector)
+	}
+
+	// A removed vector is never returned, even for its own query
+	index.Remove("7")
+	index.Remove("missing")
+	if index.Len() != 199 || index.Tombstones() != 1 {
+		t.Errorf("Len(), Tombstones() = %d, %d, want 199, 1", index.Len(), index.Tombstones())
+	}
+	ids, _ := index.Search(vectors[7], 5, nil)
+	for _, id := range ids {
+		if id == "7" {
+			t.Error("Search() returned a removed vector")
+		}
+	}
+
+	// Re-inserting an ID replaces its vector
+	index.Insert("8", vectors[9])
+	if index.Len() != 199 {
+		t.Errorf("Len() after replacing = %d, want 199", index.Len())
+	}
+	ids, _ = index.Search(vectors[9], 2, nil)
+	if len(ids) != 2 || !((ids[0] == "8" && ids[1] == "9") || (ids[0] == "9" && ids[1] == "8")) {
+		t.Errorf("Search() for a replaced vector = %q, want 8 and 9", ids)
+	}
+
+	// accept filters results without shrinking them
+	ids, _ = index.Search(vectors[0], 5, func(id string) bool {
+		n, _ := strconv.Atoi(id)
+		return n%2 == 1
+	})
+	if len(ids) != 5 {
+		t.Errorf("Search() with accept returned %d results, want 5", len(ids))
+	}
+	for _, id := range ids {
+		if n, _ := strconv.Atoi(id); n%2 != 1 {
+			t.Errorf("Search() returned %s, which accept rejects", id)
+		}
+	}
+}
+
+func TestHNSWEmpty(t *testing.T) {
+	index := newHNSWIndex(16, 200, 64)
+	if ids, _ := index.Search([]float32{1, 0}, 3, nil); len(ids) != 0 {
+		t.Errorf("Search() on an empty index = %q", ids)
+	}
+}
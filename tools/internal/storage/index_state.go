@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// IndexedState is what a repository branch was last indexed at
+type IndexedState struct {
+	Commit string `json:"commit"`
+	// Filter fingerprints the file filter the commit was indexed with, so a
+	// changed filter can force a full index
+	Filter string `json:"filter,omitempty"`
+	// Chunking describes the chunk settings the commit was indexed with, so
+	// changed chunk boundaries can force a full index
+	Chunking string `json:"chunking,omitempty"`
+}
+
+// UnmarshalJSON also accepts the bare commit SHA that older state files
+// stored
+func (st *IndexedState) UnmarshalJSON(data []byte) error {
+	var commit string
+	if err := json.Unmarshal(data, &commit); err == nil {
+		*st = IndexedState{Commit: commit}
+		return nil
+	}
+
+	type plain IndexedState
+	return json.Unmarshal(data, (*plain)(st))
+}
+
+// IndexStateStore remembers which commit was last indexed for each
+// repository branch so re-indexing can be incremental. State is kept in a
+// single JSON file; an empty path keeps it in memory only.
+type IndexStateStore struct {
+	mu     sync.Mutex
+	path   string
+	states map[string]IndexedState
+}
+
+func NewIndexStateStore(path string) (*IndexStateStore, error) {
+	s := &IndexStateStore{
+		path:   path,
+		states: make(map[string]IndexedState),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.states); err != nil {
+		return nil, fmt.Errorf("failed to parse index state: %w", err)
+	}
+
+	return s, nil
+}
+
+// IndexedState returns what a repository branch was last indexed at
+func (s *IndexStateStore) IndexedState(repository, branch string) (IndexedState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[indexStateKey(repository, branch)]
+	return state, ok
+}
+
+// SetIndexedState records what a repository branch was indexed at
+func (s *IndexStateStore) SetIndexedState(repository, branch string, state IndexedState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[indexStateKey(repository, branch)] = state
+	return s.saveLocked()
+}
+
+// ClearIndexedState forgets a repository branch, forcing the next index to
+// be a full one
+func (s *IndexStateStore) ClearIndexedState(repository, branch string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.states, indexStateKey(repository, branch))
+	return s.saveLocked()
+}
+
+// ClearRepository forgets every branch of a repository
+func (s *IndexStateStore) ClearRepository(repository string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.states {
+		if strings.HasPrefix(key, repository+"@") {
+			delete(s.states, key)
+		}
+	}
+	return s.saveLocked()
+}
+
+// Branches lists the branches of a repository with a recorded state
+func (s *IndexStateStore) Branches(repository string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var branches []string
+	for key := range s.states {
+		if branch, ok := strings.CutPrefix(key, repository+"@"); ok {
+			branches = append(branches, branch)
+		}
+	}
+	return branches
+}
+
+func (s *IndexStateStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode index state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create index state directory: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a torn file
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write index state: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to replace index state: %w", err)
+	}
+	return nil
+}
+
+func indexStateKey(repository, branch string) string {
+	return repository + "@" + branch
+}
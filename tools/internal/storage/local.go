@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"mcpserver/internal/models"
+)
+
+const localStoreLogFile = "vectors.log"
+
+var _ VectorStore = (*LocalStore)(nil)
+
+// LocalStore is a self-contained vector store persisted to a local directory.
+// Every mutation is appended to a log file which is replayed on startup and
+// compacted once it holds mostly stale records. Searches scan every vector
+// exactly unless the optional HNSW index is enabled.
+type LocalStore struct {
+	mu      sync.RWMutex
+	dir     string
+	file    *os.File
+	records int
+	vectors map[string]models.CodeChunk
+	hnsw    *hnswIndex
+}
+
+// localRecord is a single entry in the store's append-only log
+type localRecord struct {
+	Op    string            `json:"op"`
+	ID    string            `json:"id"`
+	Chunk *models.CodeChunk `json:"chunk,omitempty"`
+}
+
+// NewLocalStore opens (or creates) the store in dir. When useHNSW is set an
+// approximate nearest neighbour index is built over the loaded vectors.
+func NewLocalStore(dir string, useHNSW bool) (*LocalStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("local store directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create local store directory: %w", err)
+	}
+
+	ls := &LocalStore{
+		dir:     dir,
+		vectors: make(map[string]models.CodeChunk),
+	}
+
+	if err := ls.load(); err != nil {
+		return nil, err
+	}
+
+	// Rewrite the log when most of it is superseded or deleted records
+	if ls.records > 2*len(ls.vectors)+1000 {
+		if err := ls.compact(); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(ls.logPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local store log: %w", err)
+	}
+	ls.file = file
+
+	if useHNSW {
+		ls.rebuildIndex()
+	}
+
+	log.Printf("Local vector store opened at %s with %d vectors (HNSW: %v)", dir, len(ls.vectors), useHNSW)
+
+	return ls, nil
+}
+
+func (ls *LocalStore) Store(chunk models.CodeChunk) error {
+	return ls.StoreBatch([]models.CodeChunk{chunk})
+}
+
+func (ls *LocalStore) StoreBatch(chunks []models.CodeChunk) error {
+	records := make([]localRecord, len(chunks))
+	for i := range chunks {
+		if len(chunks[i].Embedding) == 0 {
+			return fmt.Errorf("chunk %s has no embedding", VectorID(chunks[i]))
+		}
+		records[i] = localRecord{Op: "put", ID: VectorID(chunks[i]), Chunk: &chunks[i]}
+	}
+
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if err := ls.append(records...); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		ls.vectors[record.ID] = *record.Chunk
+		if ls.hnsw != nil {
+			ls.hnsw.Insert(record.ID, record.Chunk.Embedding)
+		}
+	}
+	return nil
+}
+
+func (ls *LocalStore) Search(query []float32, filter SearchFilter, limit int) ([]models.CodeChunk, error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	if ls.hnsw == nil || limit <= 0 {
+		return exactSearch(ls.vectors, query, filter, limit), nil
+	}
+
+	ids, _ := ls.hnsw.Search(query, limit, func(id string) bool {
+		return filter.Matches(ls.vectors[id])
+	})
+
+	// The graph is shared by every repository, so a narrow filter can leave
+	// the approximate search short of results; fall back to an exact scan
+	if len(ids) < limit {
+		return exactSearch(ls.vectors, query, filter, limit), nil
+	}
+
+	results := make([]models.CodeChunk, len(ids))
+	for i, id := range ids {
+		results[i] = ls.vectors[id]
+		results[i].Embedding = nil
+	}
+	return results, nil
+}
+
+func (ls *LocalStore) Delete(ids []string) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	_, err := ls.deleteLocked(ids)
+	return err
+}
+
+func (ls *LocalStore) DeleteByFilter(filter DeleteFilter) (int, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	var ids []string
+	for id, chunk := range ls.vectors {
+		if filter.Matches(id, chunk) {
+			ids = append(ids, id)
+		}
+	}
+	return ls.deleteLocked(ids)
+}
+
+// deleteLocked removes the vectors with the given IDs and returns how many
+// existed. The caller must hold mu.
+func (ls *LocalStore) deleteLocked(ids []string) (int, error) {
+	deleted := 0
+	for _, id := range ids {
+		if _, ok := ls.vectors[id]; !ok {
+			continue
+		}
+		if err := ls.append(localRecord{Op: "delete", ID: id}); err != nil {
+			return deleted, err
+		}
+		delete(ls.vectors, id)
+		if ls.hnsw != nil {
+			ls.hnsw.Remove(id)
+		}
+		deleted++
+	}
+
+	if ls.hnsw != nil && ls.hnsw.Tombstones() > ls.hnsw.Len() {
+		ls.rebuildIndex()
+	}
+	return deleted, nil
+}
+
+func (ls *LocalStore) List(prefix string) ([]string, error) {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+
+	var ids []string
+	for id := range ls.vectors {
+		if strings.HasPrefix(id, prefix) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// Close flushes and closes the store's log file
+func (ls *LocalStore) Close() error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if ls.file == nil {
+		return nil
+	}
+	err := ls.file.Close()
+	ls.file = nil
+	return err
+}
+
+func (ls *LocalStore) logPath() string {
+	return filepath.Join(ls.dir, localStoreLogFile)
+}
+
+// load replays the log file into memory. Every record ends in a newline, so
+// a final line without one is a write torn by a crash; it is truncated away
+// so the next append starts on a fresh line. Any other unreadable record is
+// corruption.
+func (ls *LocalStore) load() error {
+	file, err := os.Open(ls.logPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open local store log: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	var offset int64
+	line := 0
+	for {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(data) > 0 {
+				log.Printf("Truncating torn local store record at line %d", line+1)
+				if err := os.Truncate(ls.logPath(), offset); err != nil {
+					return fmt.Errorf("failed to truncate torn local store record: %w", err)
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read local store log: %w", err)
+		}
+		line++
+
+		var record localRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("local store log is corrupt at line %d: %w", line, err)
+		}
+		offset += int64(len(data))
+
+		ls.records++
+		switch record.Op {
+		case "put":
+			if record.Chunk != nil {
+				ls.vectors[record.ID] = *record.Chunk
+			}
+		case "delete":
+			delete(ls.vectors, record.ID)
+		}
+	}
+}
+
+// compact rewrites the log so it holds exactly one record per live vector
+func (ls *LocalStore) compact() error {
+	tmpPath := ls.logPath() + ".tmp"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted log: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for id, chunk := range ls.vectors {
+		chunk := chunk
+		if err := encoder.Encode(localRecord{Op: "put", ID: id, Chunk: &chunk}); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write compacted log: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write compacted log: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write compacted log: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, ls.logPath()); err != nil {
+		return fmt.Errorf("failed to replace local store log: %w", err)
+	}
+
+	ls.records = len(ls.vectors)
+	return nil
+}
+
+// append writes records to the log in a single write
+func (ls *LocalStore) append(records ...localRecord) error {
+	if ls.file == nil {
+		return fmt.Errorf("local store is closed")
+	}
+
+	var data []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode record: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if _, err := ls.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+
+	ls.records += len(records)
+	return nil
+}
+
+func (ls *LocalStore) rebuildIndex() {
+	ls.hnsw = newHNSWIndex(16, 200, 64)
+	for id, chunk := range ls.vectors {
+		ls.hnsw.Insert(id, chunk.Embedding)
+	}
+}
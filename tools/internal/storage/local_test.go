@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcpserver/internal/models"
+)
+
+func TestLocalStore(t *testing.T) {
+	for _, useHNSW := range []bool{false, true} {
+		name := "exact"
+		if useHNSW {
+			name = "hnsw"
+		}
+		t.Run(name, func(t *testing.T) {
+			store, err := NewLocalStore(t.TempDir(), useHNSW)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+
+			testVectorStore(t, store)
+		})
+	}
+}
+
+func TestLocalStoreReplaysLog(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := testChunks(t)
+	if err := store.StoreBatch(chunks); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete([]string{VectorID(chunks[0])}); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := store.List("")
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Store(chunks[0]); err == nil {
+		t.Error("Store() on a closed store succeeded")
+	}
+
+	reopened, err := NewLocalStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	after, _ := reopened.List("")
+	if strings.Join(after, ",") != strings.Join(before, ",") {
+		t.Errorf("reopened store holds %q, want %q", after, before)
+	}
+}
+
+func TestLocalStoreTruncatesTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := testChunks(t)
+	if err := store.StoreBatch(chunks[:3]); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// A crash can leave a torn final record without its newline
+	appendLog(t, dir, `{"op":"put","id":"acme/api@main:torn`)
+
+	reopened, err := NewLocalStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := reopened.List(""); len(ids) != 3 {
+		t.Fatalf("reopened store holds %q, want 3 vectors", ids)
+	}
+
+	// The next batch must not be appended onto the torn fragment
+	if err := reopened.StoreBatch(chunks[3:]); err != nil {
+		t.Fatal(err)
+	}
+	reopened.Close()
+
+	again, err := NewLocalStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer again.Close()
+
+	ids, _ := again.List("")
+	if len(ids) != len(chunks) {
+		t.Errorf("store holds %q after a second reopen, want %d vectors", ids, len(chunks))
+	}
+}
+
+func TestLocalStoreRejectsCorruptLog(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := testChunks(t)
+	if err := store.Store(chunks[0]); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// A complete but unreadable record is not a torn write, even when valid
+	// records follow it
+	appendLog(t, dir, "not json\n"+`{"op":"delete","id":"acme/api@main:a.go#0"}`+"\n")
+
+	if _, err := NewLocalStore(dir, false); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("NewLocalStore() error = %v, want corruption at line 2", err)
+	}
+}
+
+// appendLog writes data to the end of the store's log
+func appendLog(t *testing.T, dir, data string) {
+	t.Helper()
+
+	logFile, err := os.OpenFile(filepath.Join(dir, localStoreLogFile), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logFile.Close()
+	if _, err := logFile.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocalStoreCompactsLog(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Overwriting one chunk leaves the log mostly superseded records
+	chunk := testChunks(t)[0]
+	for i := 0; i < 1100; i++ {
+		if err := store.Store(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewLocalStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, localStoreLogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 1 {
+		t.Errorf("compacted log holds %d records, want 1", lines)
+	}
+	if _, err := os.Stat(filepath.Join(dir, localStoreLogFile+".tmp")); !os.IsNotExist(err) {
+		t.Errorf("temporary compaction file left behind: %v", err)
+	}
+
+	results, err := reopened.Search(chunk.Embedding, SearchFilter{Repository: chunk.Repository, Branch: chunk.Branch}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Content != chunk.Content {
+		t.Errorf("Search() after compaction = %+v", results)
+	}
+
+	// The compacted log keeps accepting writes
+	if err := reopened.Store(models.CodeChunk{Repository: "acme/api", Branch: "main", FilePath: "b.go", Embedding: []float32{1}}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"mcpserver/internal/models"
+)
+
+var _ VectorStore = (*MemoryStore)(nil)
+
+// MemoryStore keeps vectors in process memory. It is intended for local
+// development and tests where no Pinecone account is available.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	vectors map[string]models.CodeChunk
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		vectors: make(map[string]models.CodeChunk),
+	}
+}
+
+func (ms *MemoryStore) Store(chunk models.CodeChunk) error {
+	return ms.StoreBatch([]models.CodeChunk{chunk})
+}
+
+func (ms *MemoryStore) StoreBatch(chunks []models.CodeChunk) error {
+	for _, chunk := range chunks {
+		if len(chunk.Embedding) == 0 {
+			return fmt.Errorf("chunk %s has no embedding", VectorID(chunk))
+		}
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, chunk := range chunks {
+		ms.vectors[VectorID(chunk)] = chunk
+	}
+	return nil
+}
+
+func (ms *MemoryStore) Search(query []float32, filter SearchFilter, limit int) ([]models.CodeChunk, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return exactSearch(ms.vectors, query, filter, limit), nil
+}
+
+func (ms *MemoryStore) Delete(ids []string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, id := range ids {
+		delete(ms.vectors, id)
+	}
+	return nil
+}
+
+func (ms *MemoryStore) DeleteByFilter(filter DeleteFilter) (int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	deleted := 0
+	for id, chunk := range ms.vectors {
+		if filter.Matches(id, chunk) {
+			delete(ms.vectors, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (ms *MemoryStore) List(prefix string) ([]string, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var ids []string
+	for id := range ms.vectors {
+		if strings.HasPrefix(id, prefix) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// exactSearch scores every vector the filter selects against query and
+// returns the closest limit chunks, best match first
+func exactSearch(vectors map[string]models.CodeChunk, query []float32, filter SearchFilter, limit int) []models.CodeChunk {
+	type scored struct {
+		chunk models.CodeChunk
+		score float64
+	}
+
+	var matches []scored
+	for _, chunk := range vectors {
+		if !filter.Matches(chunk) {
+			continue
+		}
+		matches = append(matches, scored{chunk: chunk, score: cosineSimilarity(query, chunk.Embedding)})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	results := make([]models.CodeChunk, len(matches))
+	for i, match := range matches {
+		results[i] = match.chunk
+		results[i].Embedding = nil
+	}
+
+	return results
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0 when
+// the vectors differ in length or either is all zeros
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+
+	"mcpserver/internal/models"
+)
+
+// testChunks returns chunks of two repositories, embedded with the hashing
+// embedder so searches rank them by shared words
+func testChunks(t *testing.T) []models.CodeChunk {
+	t.Helper()
+
+	chunks := []models.CodeChunk{
+		{Repository: "acme/api", Branch: "main", FilePath: "auth/login.go", Language: "Go", Content: "func login(user, password string) error"},
+		{Repository: "acme/api", Branch: "main", FilePath: "auth/login.go", Language: "Go", ChunkIndex: 1, Content: "func logout(session string)"},
+		{Repository: "acme/api", Branch: "main", FilePath: "db/query.py", Language: "Python", Content: "def run_query(sql): return cursor.execute(sql)"},
+		{Repository: "acme/api", Branch: "main", FilePath: "README.md", Language: "Markdown", Content: "How to login with a password", Documentation: true},
+		{Repository: "acme/api", Branch: "dev", FilePath: "auth/login.go", Language: "Go", Content: "func login(user, password, otp string) error"},
+		{Repository: "acme/api-gateway", Branch: "main", FilePath: "auth/login.go", Language: "Go", Content: "func login(user, password string) error"},
+	}
+
+	embedder := NewHashingEmbedder(64)
+	for i := range chunks {
+		embedding, err := embedder.GetEmbedding(chunks[i].Content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks[i].Embedding = embedding
+	}
+	return chunks
+}
+
+// testVectorStore checks the behaviour every VectorStore backend shares
+func testVectorStore(t *testing.T, store VectorStore) {
+	chunks := testChunks(t)
+	if err := store.StoreBatch(chunks); err != nil {
+		t.Fatal(err)
+	}
+	query, _ := NewHashingEmbedder(64).GetEmbedding("login password")
+	yes, no := true, false
+
+	searches := []struct {
+		name   string
+		filter SearchFilter
+		want   []string
+	}{
+		{"repository branch", SearchFilter{Repository: "acme/api", Branch: "main"}, []string{"auth/login.go", "README.md"}},
+		{"language", SearchFilter{Repository: "acme/api", Branch: "main", Languages: []string{"Python"}}, []string{"db/query.py"}},
+		{"documentation only", SearchFilter{Repository: "acme/api", Branch: "main", Documentation: &yes}, []string{"README.md"}},
+		{"code only", SearchFilter{Repository: "acme/api", Branch: "main", Documentation: &no}, []string{"auth/login.go", "auth/login.go"}},
+		{"other branch", SearchFilter{Repository: "acme/api", Branch: "dev"}, []string{"auth/login.go"}},
+		{"unknown repository", SearchFilter{Repository: "acme/web", Branch: "main"}, nil},
+	}
+	for _, tt := range searches {
+		results, err := store.Search(query, tt.filter, 2)
+		if err != nil {
+			t.Fatalf("%s: Search() error = %v", tt.name, err)
+		}
+		var got []string
+		for _, chunk := range results {
+			if chunk.Repository != tt.filter.Repository || chunk.Branch != tt.filter.Branch {
+				t.Errorf("%s: result from %s@%s", tt.name, chunk.Repository, chunk.Branch)
+			}
+			if chunk.Embedding != nil {
+				t.Errorf("%s: result carries its embedding", tt.name)
+			}
+			got = append(got, chunk.FilePath)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Search() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	ids, err := store.List("acme/api@main:auth/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"acme/api@main:auth/login.go#0", "acme/api@main:auth/login.go#1"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("List() = %q, want %q", ids, want)
+	}
+
+	if err := store.Delete([]string{"acme/api@main:auth/login.go#1", "acme/api@main:missing#0"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := store.List("acme/api@main:auth/"); len(ids) != 1 {
+		t.Errorf("List() after Delete() = %q, want one ID", ids)
+	}
+
+	deletes := []struct {
+		filter DeleteFilter
+		want   int
+	}{
+		{DeleteFilter{Repository: "acme/api", Branch: "main", Paths: []string{"README.md", "missing.go"}}, 1},
+		{DeleteFilter{Repository: "acme/api", Branch: "dev"}, 1},
+		{DeleteFilter{Repository: "acme/api"}, 2},
+		{DeleteFilter{Repository: "acme/api"}, 0},
+	}
+	for _, tt := range deletes {
+		deleted, err := store.DeleteByFilter(tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleted != tt.want {
+			t.Errorf("DeleteByFilter(%+v) = %d, want %d", tt.filter, deleted, tt.want)
+		}
+	}
+
+	// Only the sibling repository is left
+	ids, err = store.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids, []string{"acme/api-gateway@main:auth/login.go#0"}) {
+		t.Errorf("List() after purges = %q", ids)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testVectorStore(t, NewMemoryStore())
+}
+
+func TestMemoryStoreRejectsChunksWithoutEmbedding(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.Store(models.CodeChunk{Repository: "acme/api", Branch: "main", FilePath: "a.go"}); err == nil {
+		t.Error("Store() accepted a chunk without an embedding")
+	}
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var _ Embedder = (*OllamaEmbedder)(nil)
+
+// OllamaEmbedder generates embeddings with a locally hosted model through an
+// Ollama-compatible /api/embed endpoint
+type OllamaEmbedder struct {
+	baseURL    string
+	model      string
+	maxTokens  int
+	httpClient *http.Client
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error"`
+}
+
+// NewOllamaEmbedder talks to the Ollama server at baseURL. maxTokens is the
+// context length the model is served with, which Ollama does not report.
+func NewOllamaEmbedder(baseURL, model string, maxTokens int) *OllamaEmbedder {
+	log.Printf("Using Ollama embeddings at %s with model %s", baseURL, model)
+
+	return &OllamaEmbedder{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		model:     model,
+		maxTokens: maxTokens,
+		httpClient: &http.Client{
+			Timeout: 2 * time.Minute,
+		},
+	}
+}
+
+func (oe *OllamaEmbedder) MaxInputTokens() int {
+	return oe.maxTokens
+}
+
+func (oe *OllamaEmbedder) Model() string {
+	return "ollama/" + oe.model
+}
+
+func (oe *OllamaEmbedder) GetEmbedding(text string) ([]float32, error) {
+	embeddings, err := oe.GetEmbeddings([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (oe *OllamaEmbedder) GetEmbeddings(texts []string) ([][]float32, error) {
+	body, err := json.Marshal(ollamaEmbedRequest{
+		Model: oe.model,
+		Input: texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode embedding request: %w", err)
+	}
+
+	resp, err := oe.httpClient.Post(oe.baseURL+"/api/embed", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding response: %w", err)
+	}
+
+	var embedResp ollamaEmbedResponse
+	if err := json.Unmarshal(data, &embedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding request failed with status %d: %s", resp.StatusCode, embedResp.Error)
+	}
+
+	if len(embedResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embedResp.Embeddings))
+	}
+
+	return embedResp.Embeddings, nil
+}
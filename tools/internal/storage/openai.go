@@ -13,39 +13,81 @@ import (
 
 type OpenAIClient struct {
 	client *openai.Client
+	// embeddingModel is the model GetEmbeddings uses and maxInputTokens its
+	// input limit
+	embeddingModel openai.EmbeddingModel
+	maxInputTokens int
 }
 
 func NewOpenAIClient(apiKey string) *OpenAIClient {
 	log.Printf("OPENAI_API_KEY present: %v", apiKey != "")
-	
+
+	return &OpenAIClient{
+		client:         openai.NewClient(apiKey),
+		embeddingModel: openai.AdaEmbeddingV2,
+		maxInputTokens: 8191,
+	}
+}
+
+// NewOpenAICompatibleEmbedder embeds with model through the OpenAI-style
+// embeddings endpoint under baseURL, such as http://localhost:8080/v1 for a
+// llama.cpp server. apiKey may be empty for servers that do not check it.
+// maxTokens is the model's input limit, which the API does not report.
+func NewOpenAICompatibleEmbedder(baseURL, apiKey, model string, maxTokens int) *OpenAIClient {
+	log.Printf("Using OpenAI-compatible embeddings at %s with model %s", baseURL, model)
+
+	config := openai.DefaultConfig(apiKey)
+	config.BaseURL = strings.TrimSuffix(baseURL, "/")
 	return &OpenAIClient{
-		client: openai.NewClient(apiKey),
+		client:         openai.NewClientWithConfig(config),
+		embeddingModel: openai.EmbeddingModel(model),
+		maxInputTokens: maxTokens,
 	}
 }
 
+// MaxInputTokens is the input limit of the embedding model
+func (oc *OpenAIClient) MaxInputTokens() int {
+	return oc.maxInputTokens
+}
+
+func (oc *OpenAIClient) Model() string {
+	return string(oc.embeddingModel)
+}
+
 func (oc *OpenAIClient) GetEmbedding(text string) ([]float32, error) {
+	embeddings, err := oc.GetEmbeddings([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (oc *OpenAIClient) GetEmbeddings(texts []string) ([][]float32, error) {
 	resp, err := oc.client.CreateEmbeddings(
 		context.Background(),
 		openai.EmbeddingRequest{
-			Model: openai.AdaEmbeddingV2,
-			Input: []string{text},
+			Model: oc.embeddingModel,
+			Input: texts,
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(resp.Data) == 0 {
-		return nil, fmt.Errorf("no embeddings returned")
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data))
 	}
 
-	// Convert []float64 to []float32
-	embedding := make([]float32, len(resp.Data[0].Embedding))
-	for i, v := range resp.Data[0].Embedding {
-		embedding[i] = float32(v)
+	// Results carry their input index and are not guaranteed to be in order
+	embeddings := make([][]float32, len(texts))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", data.Index)
+		}
+		embeddings[data.Index] = data.Embedding
 	}
 
-	return embedding, nil
+	return embeddings, nil
 }
 
 func (oc *OpenAIClient) GenerateEnhancedSummary(chunks []map[string]interface{}, query string) (string, error) {
@@ -141,4 +183,4 @@ Make the response specific to e-commerce functionality when possible.`,
 	}
 
 	return completion.Choices[0].Message.Content, nil
-}
\ No newline at end of file
+}
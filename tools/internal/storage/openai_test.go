@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAICompatibleEmbedder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("request path = %s, want /v1/embeddings", r.URL.Path)
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Model != "nomic-embed-text" {
+			t.Errorf("model = %q, want nomic-embed-text", req.Model)
+		}
+
+		// Answer out of order, as the API allows
+		type item struct {
+			Object    string    `json:"object"`
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		var data []item
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, item{Object: "embedding", Index: i, Embedding: []float32{float32(i)}})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"object": "list", "data": data, "model": req.Model})
+	}))
+	defer server.Close()
+
+	embedder := NewOpenAICompatibleEmbedder(server.URL+"/v1/", "", "nomic-embed-text", 2048)
+	if embedder.Model() != "nomic-embed-text" || embedder.MaxInputTokens() != 2048 {
+		t.Errorf("Model(), MaxInputTokens() = %q, %d", embedder.Model(), embedder.MaxInputTokens())
+	}
+
+	embeddings, err := embedder.GetEmbeddings([]string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, embedding := range embeddings {
+		if len(embedding) != 1 || embedding[0] != float32(i) {
+			t.Errorf("embedding %d = %v, want [%d]", i, embedding, i)
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
+	"sync"
 
 	"mcpserver/internal/models"
 
@@ -12,11 +14,16 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
+var _ VectorStore = (*PineconeStore)(nil)
+
 type PineconeStore struct {
 	client      *pinecone.Client
 	indexName   string
 	environment string
 	hostUrl     string
+
+	mu        sync.Mutex
+	indexConn *pinecone.IndexConnection
 }
 
 func NewPineconeStore(apiKey, environment, indexName, hostUrl string) (*PineconeStore, error) {
@@ -43,30 +50,63 @@ func NewPineconeStore(apiKey, environment, indexName, hostUrl string) (*Pinecone
 	}, nil
 }
 
-func (ps *PineconeStore) Search(query []float32, repository string, branch string, limit int) ([]models.CodeChunk, error) {
-	ctx := context.Background()
+// index returns the connection to the Pinecone index, opening it on first use
+// so every request shares one gRPC connection
+func (ps *PineconeStore) index() (*pinecone.IndexConnection, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
 
-	fmt.Printf("Searching for repository: %s, branch: %s with limit: %d\n", repository, branch, limit)
+	if ps.indexConn != nil {
+		return ps.indexConn, nil
+	}
 
 	index, err := ps.client.Index(pinecone.NewIndexConnParams{
 		Host: ps.hostUrl,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	ps.indexConn = index
+	return index, nil
+}
+
+func (ps *PineconeStore) Search(query []float32, searchFilter SearchFilter, limit int) ([]models.CodeChunk, error) {
+	ctx := context.Background()
+
+	fmt.Printf("Searching for repository: %s, branch: %s with limit: %d\n", searchFilter.Repository, searchFilter.Branch, limit)
+
+	index, err := ps.index()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get index: %w", err)
 	}
 
 	fmt.Printf("Connected to Pinecone index: %s at %s\n", ps.indexName, ps.hostUrl)
 
-	// Convert repository and branch filter to structpb
-	filterStruct, err := structpb.NewStruct(map[string]interface{}{
-		"repository": repository,
-		"branch":     branch,
-	})
+	// Convert repository, branch, language and documentation filter to
+	// structpb
+	filter := map[string]interface{}{
+		"repository": searchFilter.Repository,
+		"branch":     searchFilter.Branch,
+	}
+	if len(searchFilter.Languages) > 0 {
+		filter["language"] = map[string]interface{}{"$in": stringList(searchFilter.Languages)}
+	}
+	if searchFilter.Documentation != nil {
+		// Vectors stored before documentation was tagged lack the field and
+		// are all code, which "$ne" still matches
+		if *searchFilter.Documentation {
+			filter["documentation"] = true
+		} else {
+			filter["documentation"] = map[string]interface{}{"$ne": true}
+		}
+	}
+	filterStruct, err := structpb.NewStruct(filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create filter: %w", err)
 	}
 
-	fmt.Printf("Using filter: repository=%s, branch=%s\n", repository, branch)
+	fmt.Printf("Using filter: %v\n", filter)
 
 	// Perform query
 	queryResp, err := index.QueryByVectorValues(ctx, &pinecone.QueryByVectorValuesRequest{
@@ -105,12 +145,23 @@ func (ps *PineconeStore) Search(query []float32, repository string, branch strin
 		metadata := match.Vector.Metadata.AsMap()
 		fmt.Printf("Match %d - ID: %s, Score: %f\n", i, match.Vector.Id, match.Score)
 
+		// Vectors stored before line ranges and symbols were recorded lack
+		// those fields
 		chunk := models.CodeChunk{
-			Content:    metadata["content"].(string),
-			FilePath:   metadata["filePath"].(string),
-			Repository: metadata["repository"].(string),
-			Branch:     metadata["branch"].(string),
-			Language:   metadata["language"].(string),
+			Content:    metadataString(metadata, "content"),
+			FilePath:   metadataString(metadata, "filePath"),
+			Repository: metadataString(metadata, "repository"),
+			Branch:     metadataString(metadata, "branch"),
+			Commit:     metadataString(metadata, "commit"),
+			Language:   metadataString(metadata, "language"),
+			ChunkIndex: metadataInt(metadata, "chunkIndex"),
+			StartLine:  metadataInt(metadata, "startLine"),
+			EndLine:    metadataInt(metadata, "endLine"),
+			Symbol:     metadataString(metadata, "symbol"),
+			SymbolKind: metadataString(metadata, "symbolKind"),
+			Headings:   metadataStrings(metadata, "headings"),
+
+			Documentation: metadataBool(metadata, "documentation"),
 		}
 
 		// Prioritize important files
@@ -135,52 +186,242 @@ func (ps *PineconeStore) Search(query []float32, repository string, branch strin
 }
 
 func (ps *PineconeStore) Store(chunk models.CodeChunk) error {
-	ctx := context.Background()
-
 	fmt.Printf("Storing chunk for repository: %s, filepath: %s\n", chunk.Repository, chunk.FilePath)
 
-	index, err := ps.client.Index(pinecone.NewIndexConnParams{
-		Host: ps.hostUrl,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to get index: %w", err)
+	return ps.StoreBatch([]models.CodeChunk{chunk})
+}
+
+func (ps *PineconeStore) StoreBatch(chunks []models.CodeChunk) error {
+	if len(chunks) == 0 {
+		return nil
 	}
 
-	// Convert metadata to structpb
-	metadata, err := structpb.NewStruct(map[string]interface{}{
-		"content":    chunk.Content,
-		"filePath":   chunk.FilePath,
-		"repository": chunk.Repository,
-		"branch":     chunk.Branch,
-		"language":   chunk.Language,
-	})
+	ctx := context.Background()
+
+	index, err := ps.index()
 	if err != nil {
-		return fmt.Errorf("failed to create metadata: %w", err)
+		return fmt.Errorf("failed to get index: %w", err)
 	}
 
-	// Create a unique ID for the vector
-	vectorId := fmt.Sprintf("%s-%s", chunk.Repository, chunk.FilePath)
-	if len(vectorId) > 100 {
-		// Ensure ID is not too long for Pinecone
-		vectorId = vectorId[:100]
-	}
+	vectors := make([]*pinecone.Vector, 0, len(chunks))
+	for _, chunk := range chunks {
+		// Convert metadata to structpb
+		metadata, err := structpb.NewStruct(map[string]interface{}{
+			"content":    chunk.Content,
+			"filePath":   chunk.FilePath,
+			"repository": chunk.Repository,
+			"branch":     chunk.Branch,
+			"commit":     chunk.Commit,
+			"language":   chunk.Language,
+			"chunkIndex": chunk.ChunkIndex,
+			"startLine":  chunk.StartLine,
+			"endLine":    chunk.EndLine,
+			"symbol":     chunk.Symbol,
+			"symbolKind": chunk.SymbolKind,
+			// Pinecone metadata cannot hold null, so chunks without
+			// headings store an empty list
+			"headings":      stringList(chunk.Headings),
+			"documentation": chunk.Documentation,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create metadata: %w", err)
+		}
 
-	// Create vector
-	vectors := []*pinecone.Vector{
-		{
-			Id:       vectorId,
+		vectors = append(vectors, &pinecone.Vector{
+			Id:       VectorID(chunk),
 			Values:   chunk.Embedding,
 			Metadata: metadata,
-		},
+		})
 	}
 
 	// Perform upsert
-	resp, err := index.UpsertVectors(ctx, vectors)
+	upserted, err := index.UpsertVectors(ctx, vectors)
 	if err != nil {
-		return fmt.Errorf("failed to store chunk: %w", err)
+		return fmt.Errorf("failed to store chunks: %w", err)
 	}
 
-	fmt.Printf("Successfully stored chunk. Upserted: %v\n", resp)
+	fmt.Printf("Successfully stored chunks. Upserted: %d\n", upserted)
 
 	return nil
-}
\ No newline at end of file
+}
+
+// pineconeDeleteBatchSize is the most IDs Pinecone deletes in one request
+const pineconeDeleteBatchSize = 1000
+
+func (ps *PineconeStore) Delete(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	index, err := ps.index()
+	if err != nil {
+		return fmt.Errorf("failed to get index: %w", err)
+	}
+
+	if err := index.DeleteVectorsById(ctx, ids); err != nil {
+		return fmt.Errorf("failed to delete vectors: %w", err)
+	}
+
+	fmt.Printf("Deleted %d vectors\n", len(ids))
+
+	return nil
+}
+
+// DeleteByFilter lists the vectors under the filter's ID prefixes and
+// deletes them in batches, since serverless indexes cannot delete by
+// metadata
+func (ps *PineconeStore) DeleteByFilter(filter DeleteFilter) (int, error) {
+	var ids []string
+	for _, prefix := range filter.idPrefixes() {
+		listed, err := ps.List(prefix)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case prefix == legacyVectorIDPrefix(filter.Repository):
+			if listed, err = ps.matchingLegacyVectors(listed, filter); err != nil {
+				return 0, err
+			}
+		case len(filter.Paths) > 0:
+			listed = slices.DeleteFunc(listed, func(id string) bool {
+				_, ok := VectorChunkIndex(id, prefix)
+				return !ok
+			})
+		}
+		ids = append(ids, listed...)
+	}
+
+	deleted := 0
+	for start := 0; start < len(ids); start += pineconeDeleteBatchSize {
+		end := min(start+pineconeDeleteBatchSize, len(ids))
+		if err := ps.Delete(ids[start:end]); err != nil {
+			return deleted, err
+		}
+		deleted += end - start
+	}
+	return deleted, nil
+}
+
+// pineconeFetchBatchSize is how many vectors matchingLegacyVectors fetches
+// per request
+const pineconeFetchBatchSize = 100
+
+// matchingLegacyVectors returns the old-style IDs among ids whose stored
+// metadata filter selects. Their shared prefix also covers repositories
+// whose name extends filter's, so the ID alone cannot tell.
+func (ps *PineconeStore) matchingLegacyVectors(ids []string, filter DeleteFilter) ([]string, error) {
+	var legacy []string
+	for _, id := range ids {
+		if isLegacyVectorID(id) {
+			legacy = append(legacy, id)
+		}
+	}
+	if len(legacy) == 0 {
+		return nil, nil
+	}
+
+	ctx := context.Background()
+
+	index, err := ps.index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index: %w", err)
+	}
+
+	var matching []string
+	for start := 0; start < len(legacy); start += pineconeFetchBatchSize {
+		end := min(start+pineconeFetchBatchSize, len(legacy))
+		resp, err := index.FetchVectors(ctx, legacy[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch vectors: %w", err)
+		}
+		for id, vector := range resp.Vectors {
+			if vector == nil || vector.Metadata == nil {
+				continue
+			}
+			metadata := vector.Metadata.AsMap()
+			chunk := models.CodeChunk{
+				Repository: metadataString(metadata, "repository"),
+				Branch:     metadataString(metadata, "branch"),
+				FilePath:   metadataString(metadata, "filePath"),
+			}
+			if filter.Matches(id, chunk) {
+				matching = append(matching, id)
+			}
+		}
+	}
+	return matching, nil
+}
+
+func (ps *PineconeStore) List(prefix string) ([]string, error) {
+	ctx := context.Background()
+
+	index, err := ps.index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index: %w", err)
+	}
+
+	// Page through all vector IDs sharing the prefix
+	var ids []string
+	var paginationToken *string
+	for {
+		resp, err := index.ListVectors(ctx, &pinecone.ListVectorsRequest{
+			Prefix:          &prefix,
+			PaginationToken: paginationToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list vectors: %w", err)
+		}
+
+		for _, id := range resp.VectorIds {
+			if id != nil {
+				ids = append(ids, *id)
+			}
+		}
+
+		if resp.NextPaginationToken == nil || *resp.NextPaginationToken == "" {
+			break
+		}
+		paginationToken = resp.NextPaginationToken
+	}
+
+	return ids, nil
+}
+
+func metadataString(metadata map[string]interface{}, key string) string {
+	value, _ := metadata[key].(string)
+	return value
+}
+
+func metadataBool(metadata map[string]interface{}, key string) bool {
+	value, _ := metadata[key].(bool)
+	return value
+}
+
+// metadataStrings reads a list of strings
+func metadataStrings(metadata map[string]interface{}, key string) []string {
+	values, _ := metadata[key].([]interface{})
+	var result []string
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// stringList converts values to the list type structpb accepts
+func stringList(values []string) []interface{} {
+	list := make([]interface{}, len(values))
+	for i, value := range values {
+		list[i] = value
+	}
+	return list
+}
+
+// metadataInt reads a number, which Pinecone returns as a float64
+func metadataInt(metadata map[string]interface{}, key string) int {
+	value, _ := metadata[key].(float64)
+	return int(value)
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"mcpserver/internal/models"
+)
+
+// SearchFilter selects the chunks a search considers
+type SearchFilter struct {
+	Repository string
+	Branch     string
+	// Languages keeps only chunks in one of these languages when not empty
+	Languages []string
+	// Documentation keeps only documentation chunks when true and only code
+	// when false; nil keeps both
+	Documentation *bool
+}
+
+// Matches reports whether the filter selects chunk
+func (f SearchFilter) Matches(chunk models.CodeChunk) bool {
+	if chunk.Repository != f.Repository || chunk.Branch != f.Branch {
+		return false
+	}
+	if len(f.Languages) > 0 && !slices.Contains(f.Languages, chunk.Language) {
+		return false
+	}
+	return f.Documentation == nil || chunk.Documentation == *f.Documentation
+}
+
+// DeleteFilter selects the vectors DeleteByFilter removes: every vector of
+// Repository, only those of Branch when it is set, and only those of Paths
+// when they are set. Paths require a Branch.
+type DeleteFilter struct {
+	Repository string
+	Branch     string
+	Paths      []string
+	// Legacy keeps only the vectors stored under the old
+	// "repository-filePath" ID scheme
+	Legacy bool
+}
+
+// Matches reports whether the filter selects chunk, stored under id
+func (f DeleteFilter) Matches(id string, chunk models.CodeChunk) bool {
+	if chunk.Repository != f.Repository {
+		return false
+	}
+	if f.Legacy && !isLegacyVectorID(id) {
+		return false
+	}
+	if f.Branch != "" && chunk.Branch != f.Branch {
+		return false
+	}
+	return len(f.Paths) == 0 || slices.Contains(f.Paths, chunk.FilePath)
+}
+
+// idPrefixes returns the vector ID prefixes that cover the filter, for
+// stores that can only select vectors by ID. A whole repository also covers
+// its IDs from the old "repository-filePath" scheme. That prefix is shared
+// with sibling repositories, such as repository-suffix, so callers must
+// check the vectors under it with Matches. Branches whose prefix was hashed
+// for length are not covered by the repository prefix, so a repository is
+// purged branch by branch as well.
+func (f DeleteFilter) idPrefixes() []string {
+	switch {
+	case f.Legacy:
+		return []string{legacyVectorIDPrefix(f.Repository)}
+	case len(f.Paths) > 0:
+		prefixes := make([]string, len(f.Paths))
+		for i, filePath := range f.Paths {
+			prefixes[i] = FileVectorIDPrefix(f.Repository, f.Branch, filePath)
+		}
+		return prefixes
+	case f.Branch != "":
+		return []string{BranchVectorIDPrefix(f.Repository, f.Branch)}
+	default:
+		return []string{f.Repository + "@", legacyVectorIDPrefix(f.Repository)}
+	}
+}
+
+// VectorStore is implemented by every vector database backend the server can use
+type VectorStore interface {
+	// Store upserts a single embedded chunk
+	Store(chunk models.CodeChunk) error
+	// StoreBatch upserts several embedded chunks in one request
+	StoreBatch(chunks []models.CodeChunk) error
+	// Search returns the chunks closest to query among those filter selects
+	Search(query []float32, filter SearchFilter, limit int) ([]models.CodeChunk, error)
+	// Delete removes the vectors with the given IDs
+	Delete(ids []string) error
+	// DeleteByFilter removes every vector filter selects and returns how
+	// many were removed
+	DeleteByFilter(filter DeleteFilter) (int, error)
+	// List returns the IDs of all vectors whose ID starts with prefix
+	List(prefix string) ([]string, error)
+}
+
+// maxVectorIDLength is the longest vector ID Pinecone accepts
+const maxVectorIDLength = 512
+
+var chunkIndexSuffix = regexp.MustCompile(`#[0-9]+$`)
+
+// VectorID builds the ID a chunk is stored under. IDs have the form
+// "repository@branch:path#index", so every chunk of a file, branch or
+// repository shares a common prefix that List can match on.
+func VectorID(chunk models.CodeChunk) string {
+	return FileVectorIDPrefix(chunk.Repository, chunk.Branch, chunk.FilePath) + strconv.Itoa(chunk.ChunkIndex)
+}
+
+// BranchVectorIDPrefix returns the prefix shared by every vector ID of a
+// repository branch
+func BranchVectorIDPrefix(repository, branch string) string {
+	prefix := repository + "@" + branch + ":"
+	if len(prefix) > maxVectorIDLength/2 {
+		prefix = "sha256:" + hashString(repository+"@"+branch) + ":"
+	}
+	return prefix
+}
+
+// FileVectorIDPrefix returns the prefix shared by every vector ID of a file.
+// Paths that would push the ID past Pinecone's limit are replaced by their
+// hash so distinct long paths never collide.
+func FileVectorIDPrefix(repository, branch, filePath string) string {
+	prefix := BranchVectorIDPrefix(repository, branch)
+	// Leave room for the chunk index suffix
+	if len(prefix)+len(filePath)+1 > maxVectorIDLength-20 {
+		return prefix + "sha256:" + hashString(filePath) + "#"
+	}
+	return prefix + filePath + "#"
+}
+
+// VectorChunkIndex returns the chunk index of id, a vector ID listed under
+// the filePrefix of a file. It returns false for the IDs of other files
+// whose path merely extends the file's, as docs/C#/intro.md does docs/C.
+func VectorChunkIndex(id, filePrefix string) (int, bool) {
+	suffix, ok := strings.CutPrefix(id, filePrefix)
+	if !ok || suffix == "" || strings.Trim(suffix, "0123456789") != "" {
+		return 0, false
+	}
+	index, err := strconv.Atoi(suffix)
+	return index, err == nil
+}
+
+// isLegacyVectorID reports whether id was produced by the old
+// "repository-filePath" scheme, in which every chunk of a file shared one ID
+func isLegacyVectorID(id string) bool {
+	return !chunkIndexSuffix.MatchString(id)
+}
+
+// legacyVectorIDPrefix returns the prefix of the old-style IDs of a
+// repository, which those of other repositories may also start with
+func legacyVectorIDPrefix(repository string) string {
+	return repository + "-"
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
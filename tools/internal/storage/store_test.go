@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"testing"
+
+	"mcpserver/internal/models"
+)
+
+func TestVectorChunkIndex(t *testing.T) {
+	prefix := FileVectorIDPrefix("acme/api", "main", "docs/C")
+
+	tests := []struct {
+		id    string
+		index int
+		ok    bool
+	}{
+		{prefix + "0", 0, true},
+		{prefix + "12", 12, true},
+		{prefix + "/intro.md#0", 0, false},
+		{prefix + "+1", 0, false},
+		{prefix, 0, false},
+		{FileVectorIDPrefix("acme/api", "main", "docs/C#/intro.md") + "3", 0, false},
+		{"acme/api@main:other.go#1", 0, false},
+	}
+
+	for _, tt := range tests {
+		index, ok := VectorChunkIndex(tt.id, prefix)
+		if index != tt.index || ok != tt.ok {
+			t.Errorf("VectorChunkIndex(%q) = %d, %v, want %d, %v", tt.id, index, ok, tt.index, tt.ok)
+		}
+	}
+}
+
+func TestDeleteFilterMatches(t *testing.T) {
+	chunk := func(repository, branch, filePath string) models.CodeChunk {
+		return models.CodeChunk{Repository: repository, Branch: branch, FilePath: filePath}
+	}
+
+	tests := []struct {
+		name   string
+		filter DeleteFilter
+		id     string
+		chunk  models.CodeChunk
+		want   bool
+	}{
+		{"repository", DeleteFilter{Repository: "acme/api"}, "acme/api@main:a.go#0", chunk("acme/api", "main", "a.go"), true},
+		{"other repository", DeleteFilter{Repository: "acme/api"}, "acme/api-gateway@main:a.go#0", chunk("acme/api-gateway", "main", "a.go"), false},
+		{"branch", DeleteFilter{Repository: "acme/api", Branch: "dev"}, "acme/api@main:a.go#0", chunk("acme/api", "main", "a.go"), false},
+		{"path", DeleteFilter{Repository: "acme/api", Branch: "main", Paths: []string{"a.go"}}, "acme/api@main:a.go#0", chunk("acme/api", "main", "a.go"), true},
+		{"other path", DeleteFilter{Repository: "acme/api", Branch: "main", Paths: []string{"b.go"}}, "acme/api@main:a.go#0", chunk("acme/api", "main", "a.go"), false},
+		{"legacy", DeleteFilter{Repository: "acme/api", Legacy: true}, "acme/api-a.go", chunk("acme/api", "main", "a.go"), true},
+		{"legacy of sibling repository", DeleteFilter{Repository: "acme/api", Legacy: true}, "acme/api-gateway-a.go", chunk("acme/api-gateway", "main", "a.go"), false},
+		{"legacy skips current IDs", DeleteFilter{Repository: "acme/api", Legacy: true}, "acme/api@main:a.go#0", chunk("acme/api", "main", "a.go"), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.Matches(tt.id, tt.chunk); got != tt.want {
+			t.Errorf("%s: Matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDeleteFilterIDPrefixes(t *testing.T) {
+	legacy := DeleteFilter{Repository: "acme/api", Legacy: true}
+	if got := legacy.idPrefixes(); len(got) != 1 || got[0] != "acme/api-" {
+		t.Errorf("legacy idPrefixes() = %q, want [acme/api-]", got)
+	}
+
+	repository := DeleteFilter{Repository: "acme/api"}
+	if got := repository.idPrefixes(); len(got) != 2 || got[0] != "acme/api@" {
+		t.Errorf("repository idPrefixes() = %q, want acme/api@ and the legacy prefix", got)
+	}
+}
@@ -0,0 +1,205 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned for archives that are neither tar.gz nor zip
+var ErrUnsupportedFormat = errors.New("unsupported archive format: expected tar.gz or zip")
+
+// Limits bound what an archive may expand to, so a small upload cannot fill
+// the disk
+type Limits struct {
+	// MaxFiles is the most regular files extracted
+	MaxFiles int
+	// MaxBytes is the most uncompressed bytes extracted in total
+	MaxBytes int64
+}
+
+// Extract unpacks a tar.gz or zip archive into destDir, detecting the format
+// from its content. Only regular files and directories are extracted;
+// symlinks, devices and entries escaping destDir are skipped. It returns the
+// directory holding the archive's content, which is the single top-level
+// directory when the archive wraps everything in one, as GitHub's do.
+func Extract(archivePath, destDir string, limits Limits) (string, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return "", ErrUnsupportedFormat
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	e := &extractor{destDir: destDir, limits: limits}
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		err = e.extractTarGz(f)
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		info, statErr := f.Stat()
+		if statErr != nil {
+			return "", fmt.Errorf("failed to read archive: %w", statErr)
+		}
+		err = e.extractZip(f, info.Size())
+	default:
+		return "", ErrUnsupportedFormat
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return contentRoot(destDir)
+}
+
+type extractor struct {
+	destDir string
+	limits  Limits
+	files   int
+	bytes   int64
+}
+
+func (e *extractor) extractTarGz(r io.Reader) error {
+	gz, err := gzip.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return fmt.Errorf("failed to read gzip stream: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar entry: %w", err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := e.mkdir(header.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := e.writeFile(header.Name, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (e *extractor) extractZip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to read zip archive: %w", err)
+	}
+
+	for _, entry := range zr.File {
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			if err := e.mkdir(entry.Name); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := entry.Open()
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", entry.Name, err)
+			}
+			err = e.writeFile(entry.Name, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// target maps an entry name to its path under destDir, rejecting names that
+// are absolute or climb out of it
+func (e *extractor) target(name string) (string, bool) {
+	name = path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", false
+	}
+	return filepath.Join(e.destDir, filepath.FromSlash(name)), true
+}
+
+func (e *extractor) mkdir(name string) error {
+	target, ok := e.target(name)
+	if !ok {
+		return nil
+	}
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	return nil
+}
+
+func (e *extractor) writeFile(name string, r io.Reader) error {
+	target, ok := e.target(name)
+	if !ok {
+		fmt.Printf("Skipping archive entry outside the extraction directory: %s\n", name)
+		return nil
+	}
+
+	e.files++
+	if e.limits.MaxFiles > 0 && e.files > e.limits.MaxFiles {
+		return fmt.Errorf("archive has more than %d files", e.limits.MaxFiles)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	defer out.Close()
+
+	// Count bytes as they are written; entry headers can lie about sizes
+	remaining := int64(-1)
+	if e.limits.MaxBytes > 0 {
+		remaining = e.limits.MaxBytes - e.bytes
+		r = io.LimitReader(r, remaining+1)
+	}
+	n, err := io.Copy(out, r)
+	e.bytes += n
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	if remaining >= 0 && n > remaining {
+		return fmt.Errorf("archive expands to more than %d bytes", e.limits.MaxBytes)
+	}
+	return nil
+}
+
+// contentRoot returns the only entry of dir when that is a directory, and dir
+// itself otherwise
+func contentRoot(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read extracted archive: %w", err)
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(dir, entries[0].Name()), nil
+	}
+	return dir, nil
+}
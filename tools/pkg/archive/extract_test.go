@@ -0,0 +1,257 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testEntry struct {
+	name    string
+	body    string
+	symlink string
+	dir     bool
+}
+
+func writeTarGz(t *testing.T, path string, entries []testEntry) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0o644, Size: int64(len(entry.body)), Typeflag: tar.TypeReg}
+		switch {
+		case entry.dir:
+			header = &tar.Header{Name: entry.name, Mode: 0o755, Typeflag: tar.TypeDir}
+		case entry.symlink != "":
+			header = &tar.Header{Name: entry.name, Mode: 0o777, Typeflag: tar.TypeSymlink, Linkname: entry.symlink}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(entry.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeZip(t *testing.T, path string, entries []testEntry) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		body := entry.body
+		switch {
+		case entry.dir:
+			header.Name = strings.TrimSuffix(entry.name, "/") + "/"
+			header.SetMode(os.ModeDir | 0o755)
+		case entry.symlink != "":
+			header.SetMode(os.ModeSymlink | 0o777)
+			body = entry.symlink
+		default:
+			header.SetMode(0o644)
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// listFiles returns the regular files under dir by slash-separated path
+func listFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			t.Errorf("symlink extracted: %s", path)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name      string
+		entries   []testEntry
+		limits    Limits
+		wantRoot  string
+		wantFiles map[string]string
+		wantErr   string
+	}{
+		{
+			name: "single top-level directory is the root",
+			entries: []testEntry{
+				{name: "api-main/", dir: true},
+				{name: "api-main/README.md", body: "# api"},
+				{name: "api-main/src/main.go", body: "package main"},
+			},
+			wantRoot:  "api-main",
+			wantFiles: map[string]string{"README.md": "# api", "src/main.go": "package main"},
+		},
+		{
+			name: "several top-level entries",
+			entries: []testEntry{
+				{name: "README.md", body: "# api"},
+				{name: "src/main.go", body: "package main"},
+			},
+			wantFiles: map[string]string{"README.md": "# api", "src/main.go": "package main"},
+		},
+		{
+			name: "entries escaping the destination are skipped",
+			entries: []testEntry{
+				{name: "README.md", body: "# api"},
+				{name: "../escaped.txt", body: "x"},
+				{name: "src/../../escaped.txt", body: "x"},
+				{name: "/tmp/escaped.txt", body: "x"},
+				{name: "..\\escaped.txt", body: "x"},
+			},
+			wantFiles: map[string]string{"README.md": "# api"},
+		},
+		{
+			name: "symlinks are skipped",
+			entries: []testEntry{
+				{name: "README.md", body: "# api"},
+				{name: "passwd", symlink: "/etc/passwd"},
+				{name: "up", symlink: ".."},
+			},
+			wantFiles: map[string]string{"README.md": "# api"},
+		},
+		{
+			name: "file limit",
+			entries: []testEntry{
+				{name: "a.go", body: "a"},
+				{name: "b.go", body: "b"},
+				{name: "c.go", body: "c"},
+			},
+			limits:  Limits{MaxFiles: 2},
+			wantErr: "more than 2 files",
+		},
+		{
+			name: "byte limit",
+			entries: []testEntry{
+				{name: "a.go", body: strings.Repeat("a", 60)},
+				{name: "b.go", body: strings.Repeat("b", 60)},
+			},
+			limits:  Limits{MaxBytes: 100},
+			wantErr: "more than 100 bytes",
+		},
+		{
+			name: "within limits",
+			entries: []testEntry{
+				{name: "a.go", body: strings.Repeat("a", 50)},
+				{name: "b.go", body: strings.Repeat("b", 50)},
+			},
+			limits:    Limits{MaxFiles: 2, MaxBytes: 100},
+			wantFiles: map[string]string{"a.go": strings.Repeat("a", 50), "b.go": strings.Repeat("b", 50)},
+		},
+	}
+
+	formats := []struct {
+		name  string
+		write func(t *testing.T, path string, entries []testEntry)
+	}{
+		{"tar.gz", writeTarGz},
+		{"zip", writeZip},
+	}
+
+	for _, format := range formats {
+		for _, tt := range tests {
+			t.Run(format.name+"/"+tt.name, func(t *testing.T) {
+				tmp := t.TempDir()
+				archivePath := filepath.Join(tmp, "archive")
+				format.write(t, archivePath, tt.entries)
+				destDir := filepath.Join(tmp, "dest")
+				if err := os.Mkdir(destDir, 0o755); err != nil {
+					t.Fatal(err)
+				}
+
+				root, err := Extract(archivePath, destDir, tt.limits)
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("Extract() error = %v, want %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if want := filepath.Join(destDir, tt.wantRoot); root != want {
+					t.Errorf("Extract() root = %s, want %s", root, want)
+				}
+				files := listFiles(t, root)
+				if len(files) != len(tt.wantFiles) {
+					t.Errorf("extracted %v, want %v", files, tt.wantFiles)
+				}
+				for name, body := range tt.wantFiles {
+					if files[name] != body {
+						t.Errorf("%s = %q, want %q", name, files[name], body)
+					}
+				}
+				if _, err := os.Stat(filepath.Join(tmp, "escaped.txt")); err == nil {
+					t.Error("entry escaped the destination directory")
+				}
+			})
+		}
+	}
+}
+
+func TestExtractUnsupportedFormat(t *testing.T) {
+	tmp := t.TempDir()
+	for name, content := range map[string]string{"text": "not an archive", "short": "PK"} {
+		archivePath := filepath.Join(tmp, name)
+		if err := os.WriteFile(archivePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Extract(archivePath, tmp, Limits{}); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("Extract(%s) error = %v, want ErrUnsupportedFormat", name, err)
+		}
+	}
+}
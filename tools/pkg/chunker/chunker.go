@@ -0,0 +1,297 @@
+package chunker
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Symbol is a named top-level declaration, such as a function or type
+type Symbol struct {
+	Name string
+	// Kind is what was declared, such as "function", "method" or "type"
+	Kind string
+}
+
+// Chunk is a contiguous run of whole lines of a file
+type Chunk struct {
+	Content string
+	// StartLine and EndLine are the 1-based, inclusive lines of the chunk
+	StartLine int
+	EndLine   int
+	// Symbol is the declaration the chunk belongs to; it is empty when the
+	// chunk spans several declarations or none
+	Symbol Symbol
+	// Headings are the titles of the document sections enclosing the chunk,
+	// outermost first. Only documentation has them.
+	Headings []string
+}
+
+// Options tunes how content is split
+type Options struct {
+	// MaxSize is the size no chunk exceeds, in the unit of Measure
+	MaxSize int
+	// Measure returns the size of a piece of text, such as its token
+	// count. It defaults to the length in bytes.
+	Measure func(text string) int
+	// Overlap is the number of lines each chunk repeats from the end of the
+	// one before it, so code cut at a boundary keeps some context. Overlap
+	// is cut short where it would push a chunk past MaxSize.
+	Overlap int
+}
+
+// splitter holds the lines of one file and their sizes
+type splitter struct {
+	lines   []string
+	sizes   []int
+	maxSize int
+	measure func(string) int
+}
+
+// unit is a span of lines that should stay in one chunk when it fits, such
+// as a function with its doc comment. Lines are 0-based and end is exclusive.
+type unit struct {
+	start, end int
+	symbol     Symbol
+	headings   []string
+}
+
+// Split cuts content into chunks of at most opts.MaxSize. For languages it
+// understands, chunk boundaries fall between top-level declarations, so
+// functions, methods and types are not split unless they are larger than
+// MaxSize on their own. Markdown and reStructuredText are split along their
+// heading hierarchy instead. Other content is split at line boundaries, and
+// lines longer than MaxSize are cut.
+func Split(content, language string, opts Options) []Chunk {
+	if content == "" {
+		return nil
+	}
+
+	s := &splitter{
+		lines:   strings.Split(content, "\n"),
+		maxSize: opts.MaxSize,
+		measure: opts.Measure,
+	}
+	if s.measure == nil {
+		s.measure = func(text string) int { return len(text) }
+	}
+	if s.maxSize <= 0 {
+		s.maxSize = 1
+	}
+
+	// Each line costs its own size plus one for the newline joining it to
+	// the next, which keeps the sum of line sizes an upper bound
+	s.sizes = make([]int, len(s.lines))
+	for i, line := range s.lines {
+		s.sizes[i] = s.measure(line) + 1
+	}
+
+	var chunks []Chunk
+	if units, ok := s.units(content, language); ok {
+		chunks = s.pack(units)
+	} else {
+		chunks = s.splitLines(unit{start: 0, end: len(s.lines)})
+	}
+	return s.overlap(chunks, opts.Overlap)
+}
+
+// units partitions the lines into declaration- or section-aligned units, or
+// returns false when language is not understood or content cannot be parsed
+func (s *splitter) units(content, language string) ([]unit, bool) {
+	if language == "Go" {
+		return goUnits(content, len(s.lines))
+	}
+	if parse, ok := documentHeadings[language]; ok {
+		return s.sectionUnits(0, len(s.lines), parse(s.lines), nil), true
+	}
+	if style, ok := languageStyles[language]; ok {
+		return style.units(s.lines), true
+	}
+	return nil, false
+}
+
+// pack merges consecutive units into chunks of up to maxSize. Units that are
+// too large on their own are split at line boundaries.
+func (s *splitter) pack(units []unit) []Chunk {
+	var chunks []Chunk
+	start, end, size := 0, 0, 0
+	var symbols []Symbol
+	// headings are those shared by every non-blank unit in the chunk
+	var headings []string
+	hasText := false
+
+	flush := func() {
+		if end > start {
+			chunk := s.newChunk(start, end)
+			if len(symbols) == 1 {
+				chunk.Symbol = symbols[0]
+			}
+			chunk.Headings = headings
+			chunks = append(chunks, chunk)
+		}
+		start, size, symbols, headings, hasText = end, 0, nil, nil, false
+	}
+
+	for _, u := range units {
+		unitSize := s.spanSize(u.start, u.end)
+
+		if unitSize > s.maxSize {
+			flush()
+			chunks = append(chunks, s.splitLines(u)...)
+			start, end = u.end, u.end
+			continue
+		}
+
+		if size > 0 && size+unitSize > s.maxSize {
+			flush()
+		}
+		end = u.end
+		size += unitSize
+		if u.symbol != (Symbol{}) {
+			symbols = append(symbols, u.symbol)
+		}
+		if !s.blank(u.start, u.end) {
+			if hasText {
+				headings = commonCrumbs(headings, u.headings)
+			} else {
+				headings, hasText = u.headings, true
+			}
+		}
+	}
+	flush()
+
+	return dropBlank(chunks)
+}
+
+// splitLines cuts the lines of u into chunks of up to maxSize, breaking
+// between lines where possible. Every chunk belongs to the symbol of u.
+func (s *splitter) splitLines(u unit) []Chunk {
+	var chunks []Chunk
+	chunkStart, size := u.start, 0
+
+	for i := u.start; i < u.end; i++ {
+		if size > 0 && size+s.sizes[i] > s.maxSize {
+			chunks = append(chunks, s.newChunk(chunkStart, i))
+			chunkStart, size = i, 0
+		}
+		if s.sizes[i] > s.maxSize {
+			chunks = append(chunks, s.cutLine(i)...)
+			chunkStart = i + 1
+			continue
+		}
+		size += s.sizes[i]
+	}
+	if chunkStart < u.end {
+		chunks = append(chunks, s.newChunk(chunkStart, u.end))
+	}
+	for i := range chunks {
+		chunks[i].Symbol = u.symbol
+		chunks[i].Headings = u.headings
+	}
+
+	return dropBlank(chunks)
+}
+
+// cutLine splits a single line longer than maxSize into pieces that fit,
+// all reporting the same line number
+func (s *splitter) cutLine(i int) []Chunk {
+	var chunks []Chunk
+	rest := s.lines[i]
+	for rest != "" {
+		n := s.fittingPrefix(rest)
+		chunks = append(chunks, Chunk{Content: rest[:n], StartLine: i + 1, EndLine: i + 1})
+		rest = rest[n:]
+	}
+	return chunks
+}
+
+// fittingPrefix returns the length of the longest prefix of text, cut at a
+// rune boundary, that measures at most maxSize. It is at least one rune.
+func (s *splitter) fittingPrefix(text string) int {
+	best := runeEnd(text, 1)
+	low, high := 1, utf8.RuneCountInString(text)
+	for low <= high {
+		mid := (low + high) / 2
+		end := runeEnd(text, mid)
+		if s.measure(text[:end]) <= s.maxSize {
+			best, low = end, mid+1
+		} else {
+			high = mid - 1
+		}
+	}
+	return best
+}
+
+// runeEnd returns the byte offset after the first n runes of text
+func runeEnd(text string, n int) int {
+	for i := range text {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(text)
+}
+
+// overlap extends every chunk but the first back by up to n lines, stopping
+// early where that would make it larger than maxSize
+func (s *splitter) overlap(chunks []Chunk, n int) []Chunk {
+	if n <= 0 {
+		return chunks
+	}
+	for i := 1; i < len(chunks); i++ {
+		chunk := &chunks[i]
+		// Pieces of a cut line have nothing to take along
+		if chunk.StartLine == chunk.EndLine && s.sizes[chunk.StartLine-1] > s.maxSize {
+			continue
+		}
+		start := chunk.StartLine - 1
+		size := s.spanSize(start, chunk.EndLine)
+		for start > 0 && chunk.StartLine-1-start < n && size+s.sizes[start-1] <= s.maxSize {
+			start--
+			size += s.sizes[start]
+		}
+		if start != chunk.StartLine-1 {
+			chunk.StartLine = start + 1
+			chunk.Content = strings.Join(s.lines[start:chunk.EndLine], "\n")
+		}
+	}
+	return chunks
+}
+
+func (s *splitter) newChunk(start, end int) Chunk {
+	return Chunk{
+		Content:   strings.Join(s.lines[start:end], "\n"),
+		StartLine: start + 1,
+		EndLine:   end,
+	}
+}
+
+// spanSize is the size of lines[start:end] joined by newlines
+func (s *splitter) spanSize(start, end int) int {
+	size := 0
+	for _, lineSize := range s.sizes[start:end] {
+		size += lineSize
+	}
+	return size
+}
+
+// blank reports whether lines[start:end] hold nothing but whitespace
+func (s *splitter) blank(start, end int) bool {
+	for _, line := range s.lines[start:end] {
+		if strings.TrimSpace(line) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// dropBlank removes chunks that hold nothing but whitespace
+func dropBlank(chunks []Chunk) []Chunk {
+	kept := chunks[:0]
+	for _, chunk := range chunks {
+		if strings.TrimSpace(chunk.Content) != "" {
+			kept = append(kept, chunk)
+		}
+	}
+	return kept
+}
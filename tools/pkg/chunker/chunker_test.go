@@ -0,0 +1,112 @@
+package chunker
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"mcpserver/pkg/tokenizer"
+)
+
+// numberedLines returns n lines "line 1" through "line n"
+func numberedLines(n int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %02d", i+1)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestSplitLineRanges(t *testing.T) {
+	// Every line is "line NN" plus a newline, 8 bytes
+	content := numberedLines(10)
+
+	tests := []struct {
+		name    string
+		opts    Options
+		content string
+		want    [][2]int
+	}{
+		{"fits in one chunk", Options{MaxSize: 1000}, content, [][2]int{{1, 10}}},
+		{"four lines per chunk", Options{MaxSize: 32}, content, [][2]int{{1, 4}, {5, 8}, {9, 10}}},
+		{"six lines per chunk", Options{MaxSize: 48}, content, [][2]int{{1, 6}, {7, 10}}},
+		{"overlap of one line", Options{MaxSize: 48, Overlap: 1}, content, [][2]int{{1, 6}, {6, 10}}},
+		{"overlap of two lines", Options{MaxSize: 48, Overlap: 2}, content, [][2]int{{1, 6}, {5, 10}}},
+		// Overlap never pushes a chunk past MaxSize
+		{"overlap cut short", Options{MaxSize: 48, Overlap: 3}, content, [][2]int{{1, 6}, {5, 10}}},
+		{"full chunks take no overlap", Options{MaxSize: 32, Overlap: 1}, numberedLines(8), [][2]int{{1, 4}, {5, 8}}},
+		{"blank chunks dropped", Options{MaxSize: 4}, "a" + strings.Repeat("\n", 10) + "b", [][2]int{{1, 3}, {11, 11}}},
+		{"empty content", Options{MaxSize: 10}, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := Split(tt.content, "", tt.opts)
+			lines := strings.Split(tt.content, "\n")
+
+			var got [][2]int
+			for _, chunk := range chunks {
+				got = append(got, [2]int{chunk.StartLine, chunk.EndLine})
+				if want := strings.Join(lines[chunk.StartLine-1:chunk.EndLine], "\n"); chunk.Content != want {
+					t.Errorf("chunk %d-%d content = %q, want %q", chunk.StartLine, chunk.EndLine, chunk.Content, want)
+				}
+				if len(chunk.Content) > tt.opts.MaxSize {
+					t.Errorf("chunk %d-%d is %d bytes, over %d", chunk.StartLine, chunk.EndLine, len(chunk.Content), tt.opts.MaxSize)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("line ranges = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("line ranges = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitCutsLongLines(t *testing.T) {
+	content := "short\n" + strings.Repeat("é", 10) + "\nend"
+	chunks := Split(content, "", Options{MaxSize: 7, Overlap: 1})
+
+	var pieces []string
+	for _, chunk := range chunks {
+		if len(chunk.Content) > 7 {
+			t.Errorf("chunk %q is over 7 bytes", chunk.Content)
+		}
+		if chunk.StartLine == 2 && chunk.EndLine == 2 {
+			pieces = append(pieces, chunk.Content)
+		}
+	}
+
+	// The long line is cut at rune boundaries and its pieces take no overlap
+	if strings.Join(pieces, "") != strings.Repeat("é", 10) {
+		t.Errorf("pieces of line 2 = %q", pieces)
+	}
+	if first := chunks[0]; first.Content != "short" || first.StartLine != 1 {
+		t.Errorf("first chunk = %+v", first)
+	}
+	if last := chunks[len(chunks)-1]; last.Content != "end" || last.StartLine != 3 {
+		t.Errorf("last chunk = %+v", last)
+	}
+}
+
+func TestSplitByTokens(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&b, "\tresult%d := compute(input[%d], 日本語)\n", i, i)
+	}
+	content := b.String()
+
+	const maxTokens = 64
+	chunks := Split(content, "", Options{MaxSize: maxTokens, Measure: tokenizer.CountTokens})
+	if len(chunks) < 2 {
+		t.Fatalf("Split() returned %d chunks", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if tokens := tokenizer.CountTokens(chunk.Content); tokens > maxTokens {
+			t.Errorf("chunk %d-%d is %d tokens, over %d", chunk.StartLine, chunk.EndLine, tokens, maxTokens)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package chunker
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// heading is a section title found in a document. line is the 0-based line
+// the section starts at, which for underlined titles is the title itself.
+type heading struct {
+	line  int
+	level int
+	title string
+}
+
+// documentHeadings maps documentation languages to their heading parsers
+var documentHeadings = map[string]func(lines []string) []heading{
+	"Markdown":         markdownHeadings,
+	"reStructuredText": rstHeadings,
+}
+
+var (
+	atxHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextLine    = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	markdownFence = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	listItem      = regexp.MustCompile(`^\s*([-*+>]|\d+[.)])(\s|$)`)
+)
+
+// markdownHeadings finds "#" headings and "===" or "---" underlined ones,
+// skipping fenced code blocks and front matter
+func markdownHeadings(lines []string) []heading {
+	var headings []heading
+	start := frontMatterEnd(lines)
+	fence := ""
+
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := markdownFence.FindStringSubmatch(line); m != nil {
+			switch {
+			case fence == "":
+				fence = m[1]
+			case m[1][0] == fence[0] && len(m[1]) >= len(fence) && strings.TrimSpace(line[len(m[0]):]) == "":
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		if m := atxHeading.FindStringSubmatch(line); m != nil {
+			headings = append(headings, heading{line: i, level: len(m[1]), title: m[2]})
+			continue
+		}
+
+		// An underline turns the paragraph line above it into a heading
+		if m := setextLine.FindStringSubmatch(line); m != nil && i > start {
+			title := lines[i-1]
+			if strings.TrimSpace(title) == "" || strings.HasPrefix(title, "    ") || listItem.MatchString(title) || markdownFence.MatchString(title) {
+				continue
+			}
+			if n := len(headings); n > 0 && headings[n-1].line == i-1 {
+				continue
+			}
+			level := 1
+			if m[1][0] == '-' {
+				level = 2
+			}
+			headings = append(headings, heading{line: i - 1, level: level, title: strings.TrimSpace(title)})
+		}
+	}
+	return headings
+}
+
+// frontMatterEnd returns the line after a YAML front matter block opening
+// the document, or 0 when there is none
+func frontMatterEnd(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if trimmed := strings.TrimSpace(lines[i]); trimmed == "---" || trimmed == "..." {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// rstHeadings finds reStructuredText section titles: a line underlined, and
+// optionally overlined, with a repeated punctuation character. Levels follow
+// the order in which each adornment style first appears.
+func rstHeadings(lines []string) []heading {
+	var headings []heading
+	levels := make(map[string]int)
+
+	for i := 1; i < len(lines); i++ {
+		char, length := rstAdornment(lines[i])
+		if length == 0 {
+			continue
+		}
+		title := strings.TrimSpace(lines[i-1])
+		if title == "" || lines[i-1][0] == ' ' || lines[i-1][0] == '\t' {
+			continue
+		}
+		if c, _ := rstAdornment(lines[i-1]); c != 0 {
+			continue
+		}
+		if length < utf8.RuneCountInString(title) {
+			continue
+		}
+
+		start, style := i-1, string(char)
+		if i >= 2 {
+			if overChar, overLength := rstAdornment(lines[i-2]); overChar == char && overLength == length {
+				start, style = i-2, "over"+style
+			}
+		}
+		if _, ok := levels[style]; !ok {
+			levels[style] = len(levels) + 1
+		}
+		headings = append(headings, heading{line: start, level: levels[style], title: title})
+	}
+	return headings
+}
+
+// rstAdornment returns the character an adornment line repeats and its
+// length, or zero when line is not one
+func rstAdornment(line string) (byte, int) {
+	line = strings.TrimRight(line, " \t")
+	if len(line) < 2 {
+		return 0, 0
+	}
+	c := line[0]
+	if c > '~' || c < '!' || (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') {
+		return 0, 0
+	}
+	for i := 1; i < len(line); i++ {
+		if line[i] != c {
+			return 0, 0
+		}
+	}
+	return c, len(line)
+}
+
+// sectionUnits splits lines[start:end] along the heading hierarchy. A span
+// that fits in maxSize stays whole; otherwise its text before the first
+// subsection becomes one unit and each top-level subsection is split the
+// same way, so chunks break at the highest heading level that makes them
+// fit. headings are those inside the span and crumbs the titles of the
+// sections enclosing it.
+func (s *splitter) sectionUnits(start, end int, headings []heading, crumbs []string) []unit {
+	if len(headings) == 0 || s.spanSize(start, end) <= s.maxSize {
+		return []unit{{start: start, end: end, headings: crumbs}}
+	}
+
+	top := headings[0].level
+	for _, h := range headings {
+		top = min(top, h.level)
+	}
+
+	var units []unit
+	first := 0
+	for headings[first].level != top {
+		first++
+	}
+	if headings[first].line > start {
+		units = append(units, s.sectionUnits(start, headings[first].line, headings[:first], crumbs)...)
+	}
+
+	for i := first; i < len(headings); {
+		h := headings[i]
+		next := i + 1
+		for next < len(headings) && headings[next].level != top {
+			next++
+		}
+		sectionEnd := end
+		if next < len(headings) {
+			sectionEnd = headings[next].line
+		}
+
+		section := s.sectionUnits(h.line, sectionEnd, headings[i+1:next], appendCrumb(crumbs, h.title))
+		section[0].symbol = Symbol{Name: h.title, Kind: "section"}
+		units = append(units, section...)
+		i = next
+	}
+	return units
+}
+
+// appendCrumb returns crumbs followed by title without sharing crumbs'
+// backing array, which sibling sections also extend
+func appendCrumb(crumbs []string, title string) []string {
+	return append(crumbs[:len(crumbs):len(crumbs)], title)
+}
+
+// commonCrumbs returns the longest shared prefix of a and b
+func commonCrumbs(a, b []string) []string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
@@ -0,0 +1,205 @@
+package chunker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownHeadings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []heading
+	}{
+		{
+			name:    "atx",
+			content: "# Title\ntext\n## Usage ##\n###### Deep\n####### Not a heading",
+			want:    []heading{{0, 1, "Title"}, {2, 2, "Usage"}, {3, 6, "Deep"}},
+		},
+		{
+			name:    "atx needs a space",
+			content: "#hashtag\n#\n    # indented code",
+			want:    []heading{{1, 1, ""}},
+		},
+		{
+			name:    "setext",
+			content: "Title\n=====\n\nSection\n---\n",
+			want:    []heading{{0, 1, "Title"}, {3, 2, "Section"}},
+		},
+		{
+			name:    "setext needs a paragraph line",
+			content: "text\n\n---\n- item\n---\n    code\n---",
+			want:    nil,
+		},
+		{
+			name:    "underline below atx heading",
+			content: "# Title\n---",
+			want:    []heading{{0, 1, "Title"}},
+		},
+		{
+			name:    "fenced code",
+			content: "# Before\n```sh\n# comment\nx\n---\n```\n# After",
+			want:    []heading{{0, 1, "Before"}, {6, 1, "After"}},
+		},
+		{
+			name:    "fence closed only by a longer or equal fence of its kind",
+			content: "````\n```\n~~~~\n# Inside\n````\n# Outside",
+			want:    []heading{{5, 1, "Outside"}},
+		},
+		{
+			name:    "front matter",
+			content: "---\ntitle: Post\n# not a heading\n---\n# Body",
+			want:    []heading{{4, 1, "Body"}},
+		},
+		{
+			name:    "unclosed front matter is a thematic break",
+			content: "---\n# Title",
+			want:    []heading{{1, 1, "Title"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := markdownHeadings(strings.Split(tt.content, "\n"))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("markdownHeadings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRSTHeadings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []heading
+	}{
+		{
+			name:    "underlined",
+			content: "Title\n=====\n\nSection\n-------\n\nOther\n=====",
+			want:    []heading{{0, 1, "Title"}, {3, 2, "Section"}, {6, 1, "Other"}},
+		},
+		{
+			name:    "overlined is its own style",
+			content: "=====\nTitle\n=====\n\nIntro\n=====",
+			want:    []heading{{0, 1, "Title"}, {4, 2, "Intro"}},
+		},
+		{
+			name:    "underline shorter than title",
+			content: "Long title\n===",
+			want:    nil,
+		},
+		{
+			name:    "underline counts runes",
+			content: "Über\n====",
+			want:    []heading{{0, 1, "Über"}},
+		},
+		{
+			name:    "indented title",
+			content: "  quoted\n--------",
+			want:    nil,
+		},
+		{
+			name:    "transition between adornments",
+			content: "text\n\n----\n\n----",
+			want:    nil,
+		},
+		{
+			name:    "letters are no adornment",
+			content: "Title\naaaaa",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rstHeadings(strings.Split(tt.content, "\n"))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rstHeadings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitDocumentSections(t *testing.T) {
+	content := strings.Join([]string{
+		"# Guide",         // 1
+		"Intro text.",     // 2
+		"",                // 3
+		"## Install",      // 4
+		"Run the script.", // 5
+		"",                // 6
+		"### Linux",       // 7
+		"apt install x",   // 8
+		"",                // 9
+		"### macOS",       // 10
+		"brew install x",  // 11
+		"",                // 12
+		"## Usage",        // 13
+		"Call x --help.",  // 14
+	}, "\n")
+
+	type section struct {
+		start, end int
+		symbol     string
+		headings   []string
+	}
+	tests := []struct {
+		name    string
+		maxSize int
+		want    []section
+	}{
+		{
+			name:    "fits in one chunk",
+			maxSize: 1000,
+			want:    []section{{1, 14, "", nil}},
+		},
+		{
+			name:    "split at second level",
+			maxSize: 80,
+			want: []section{
+				{1, 3, "Guide", []string{"Guide"}},
+				{4, 12, "Install", []string{"Guide", "Install"}},
+				{13, 14, "Usage", []string{"Guide", "Usage"}},
+			},
+		},
+		{
+			name:    "split at third level",
+			maxSize: 40,
+			want: []section{
+				{1, 3, "Guide", []string{"Guide"}},
+				{4, 6, "Install", []string{"Guide", "Install"}},
+				{7, 9, "Linux", []string{"Guide", "Install", "Linux"}},
+				{10, 12, "macOS", []string{"Guide", "Install", "macOS"}},
+				{13, 14, "Usage", []string{"Guide", "Usage"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []section
+			for _, chunk := range Split(content, "Markdown", Options{MaxSize: tt.maxSize}) {
+				got = append(got, section{chunk.StartLine, chunk.EndLine, chunk.Symbol.Name, chunk.Headings})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitRSTHeadings(t *testing.T) {
+	content := "=====\nGuide\n=====\n\nInstall\n-------\nRun it.\n\nUsage\n-----\nCall it."
+
+	chunks := Split(content, "reStructuredText", Options{MaxSize: 30})
+	var got [][]string
+	for _, chunk := range chunks {
+		got = append(got, chunk.Headings)
+	}
+	want := [][]string{{"Guide"}, {"Guide", "Install"}, {"Guide", "Usage"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("headings = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package chunker
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// goUnits splits Go source into one unit per top-level declaration, each
+// starting at its doc comment. The package clause and anything before the
+// first declaration form the first unit.
+func goUnits(content string, lineCount int) ([]unit, bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return nil, false
+	}
+
+	var boundaries []boundary
+	for _, decl := range file.Decls {
+		pos := decl.Pos()
+		if doc := declDoc(decl); doc != nil {
+			pos = doc.Pos()
+		}
+		boundaries = append(boundaries, boundary{line: fset.Position(pos).Line - 1, symbol: goSymbol(decl)})
+	}
+
+	return unitsFromBoundaries(boundaries, lineCount), true
+}
+
+// goSymbol names a declaration. Grouped declarations are named after their
+// first spec.
+func goSymbol(decl ast.Decl) Symbol {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv == nil || len(d.Recv.List) == 0 {
+			return Symbol{Name: d.Name.Name, Kind: "function"}
+		}
+		return Symbol{Name: receiverType(d.Recv.List[0].Type) + "." + d.Name.Name, Kind: "method"}
+	case *ast.GenDecl:
+		if len(d.Specs) == 0 {
+			return Symbol{}
+		}
+		switch spec := d.Specs[0].(type) {
+		case *ast.TypeSpec:
+			return Symbol{Name: spec.Name.Name, Kind: "type"}
+		case *ast.ValueSpec:
+			if d.Tok == token.CONST {
+				return Symbol{Name: spec.Names[0].Name, Kind: "const"}
+			}
+			return Symbol{Name: spec.Names[0].Name, Kind: "var"}
+		}
+	}
+	return Symbol{}
+}
+
+// receiverType returns the type name of a method receiver, without pointer
+// or type parameters
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	}
+	return nil
+}
+
+// boundary is the 0-based line a declaration starts at
+type boundary struct {
+	line   int
+	symbol Symbol
+}
+
+// unitsFromBoundaries partitions lineCount lines into units beginning at each
+// of the increasing boundaries. Lines before the first boundary form a unit
+// of their own, and lines after a unit's declaration stay with it.
+func unitsFromBoundaries(boundaries []boundary, lineCount int) []unit {
+	units := []unit{{start: 0}}
+	for _, b := range boundaries {
+		last := &units[len(units)-1]
+		if b.line < last.start || b.line >= lineCount {
+			continue
+		}
+		if b.line == last.start {
+			if last.symbol == (Symbol{}) {
+				last.symbol = b.symbol
+			}
+			continue
+		}
+		last.end = b.line
+		units = append(units, unit{start: b.line, symbol: b.symbol})
+	}
+	units[len(units)-1].end = lineCount
+	return units
+}
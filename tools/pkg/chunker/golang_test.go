@@ -0,0 +1,110 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoUnits(t *testing.T) {
+	content := `// Package shapes draws shapes
+package shapes
+
+import "math"
+
+// Pi is pi
+const (
+	Pi = math.Pi
+	E  = math.E
+)
+
+var scale = 2
+
+// Shape is a thing
+type Shape interface {
+	Area() float64
+}
+
+type List[T any] []T
+
+// Len counts the items
+func (l *List[T]) Len() int {
+	return len(l)
+}
+
+func (c Circle) Area() float64 { return Pi }
+
+func New() *List[int] {
+	return nil
+}
+`
+	lines := strings.Split(content, "\n")
+	units, ok := goUnits(content, len(lines))
+	if !ok {
+		t.Fatal("goUnits() failed to parse")
+	}
+
+	want := []struct {
+		start, end int
+		symbol     Symbol
+	}{
+		{0, 3, Symbol{}},
+		// The import declaration has no symbol
+		{3, 5, Symbol{}},
+		{5, 11, Symbol{Name: "Pi", Kind: "const"}},
+		{11, 13, Symbol{Name: "scale", Kind: "var"}},
+		{13, 18, Symbol{Name: "Shape", Kind: "type"}},
+		{18, 20, Symbol{Name: "List", Kind: "type"}},
+		{20, 25, Symbol{Name: "List.Len", Kind: "method"}},
+		{25, 27, Symbol{Name: "Circle.Area", Kind: "method"}},
+		{27, len(lines), Symbol{Name: "New", Kind: "function"}},
+	}
+	if len(units) != len(want) {
+		t.Fatalf("goUnits() = %+v, want %d units", units, len(want))
+	}
+	for i, w := range want {
+		if units[i].start != w.start || units[i].end != w.end || units[i].symbol != w.symbol {
+			t.Errorf("unit %d = %d-%d %+v, want %d-%d %+v", i, units[i].start, units[i].end, units[i].symbol, w.start, w.end, w.symbol)
+		}
+	}
+
+	if _, ok := goUnits("package main\n\nfunc broken( {\n", 3); ok {
+		t.Error("goUnits() parsed invalid Go")
+	}
+}
+
+func TestSplitGoKeepsDeclarationsWhole(t *testing.T) {
+	content := `package main
+
+// first does one thing
+func first() {
+	println("one")
+}
+
+func second() {
+	println("two")
+}
+`
+	chunks := Split(content, "Go", Options{MaxSize: 60})
+
+	want := []struct {
+		startLine, endLine int
+		symbol             string
+	}{
+		{1, 2, ""},
+		{3, 7, "first"},
+		{8, 11, "second"},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("Split() = %+v, want %d chunks", chunks, len(want))
+	}
+	for i, w := range want {
+		if chunks[i].StartLine != w.startLine || chunks[i].EndLine != w.endLine || chunks[i].Symbol.Name != w.symbol {
+			t.Errorf("chunk %d = lines %d-%d %q, want %d-%d %q", i, chunks[i].StartLine, chunks[i].EndLine, chunks[i].Symbol.Name, w.startLine, w.endLine, w.symbol)
+		}
+	}
+
+	// Unparseable Go falls back to splitting by line
+	if chunks := Split("package main\nfunc broken( {\n", "Go", Options{MaxSize: 1000}); len(chunks) != 1 || chunks[0].Symbol != (Symbol{}) {
+		t.Errorf("Split() of invalid Go = %+v", chunks)
+	}
+}
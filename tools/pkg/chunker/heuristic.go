@@ -0,0 +1,213 @@
+package chunker
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// style describes how to find top-level declarations in a language without
+// a full parser
+type style struct {
+	// braces marks languages whose blocks are delimited by braces; a
+	// declaration can then only start where the brace depth is zero
+	braces bool
+	// charLiterals marks languages whose single quotes only delimit
+	// character literals, so a Rust lifetime such as 'a opens no string
+	charLiterals bool
+	// declaration matches the first line of a top-level declaration. When
+	// nil, every line at depth zero and column zero may start one.
+	declaration *regexp.Regexp
+	// attached lists line prefixes, such as comments and decorators, that
+	// belong to the declaration below them
+	attached []string
+}
+
+var (
+	// keywordSymbol finds a declaration keyword and the name after it
+	keywordSymbol = regexp.MustCompile(`\b(function|fn|class|interface|enum|struct|namespace|module|trait|def|type)\s*\*?\s+([A-Za-z_$][\w$]*)`)
+	// assignedFunction finds "const name = (...) =>" and similar
+	assignedFunction = regexp.MustCompile(`\b(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*=\s*(?:async\s*)?(?:function\b|\([^)]*\)\s*=>|[A-Za-z_$][\w$]*\s*=>)`)
+	// calledName finds the name before the parameter list of a C-style
+	// function or method signature
+	calledName = regexp.MustCompile(`([A-Za-z_][\w]*)\s*\(`)
+)
+
+// symbolKinds maps declaration keywords to symbol kinds
+var symbolKinds = map[string]string{
+	"function":  "function",
+	"fn":        "function",
+	"def":       "function",
+	"class":     "class",
+	"interface": "interface",
+	"enum":      "enum",
+	"struct":    "struct",
+	"namespace": "namespace",
+	"module":    "module",
+	"trait":     "trait",
+	"type":      "type",
+}
+
+// lineSymbol guesses what the first line of a declaration declares
+func (s style) lineSymbol(line string) Symbol {
+	if m := keywordSymbol.FindStringSubmatch(line); m != nil {
+		return Symbol{Name: m[2], Kind: symbolKinds[m[1]]}
+	}
+	if m := assignedFunction.FindStringSubmatch(line); m != nil {
+		return Symbol{Name: m[1], Kind: "function"}
+	}
+	if s.braces && !strings.ContainsAny(line, "=;") {
+		if m := calledName.FindStringSubmatch(line); m != nil {
+			return Symbol{Name: m[1], Kind: "function"}
+		}
+	}
+	return Symbol{}
+}
+
+var (
+	cStyleAttached = []string{"//", "/*", "*", "@", "#["}
+	hashAttached   = []string{"#", "@"}
+)
+
+// languageStyles maps the names of the language package to how their
+// declarations are found
+var languageStyles = map[string]style{
+	"JavaScript":       {braces: true, attached: cStyleAttached},
+	"TypeScript":       {braces: true, attached: cStyleAttached},
+	"Java":             {braces: true, attached: cStyleAttached},
+	"C/C++":            {braces: true, attached: cStyleAttached},
+	"C#":               {braces: true, attached: append([]string{"["}, cStyleAttached...)},
+	"PHP":              {braces: true, attached: append([]string{"#"}, cStyleAttached...)},
+	"CSS":              {braces: true, attached: cStyleAttached},
+	"SCSS":             {braces: true, attached: cStyleAttached},
+	"Less":             {braces: true, attached: cStyleAttached},
+	"Rust":             {braces: true, charLiterals: true, attached: cStyleAttached},
+	"Kotlin":           {braces: true, attached: cStyleAttached},
+	"Swift":            {braces: true, attached: cStyleAttached},
+	"Scala":            {braces: true, attached: cStyleAttached},
+	"Groovy":           {braces: true, attached: cStyleAttached},
+	"Dart":             {braces: true, attached: cStyleAttached},
+	"Objective-C":      {braces: true, attached: cStyleAttached},
+	"Protocol Buffers": {braces: true, attached: cStyleAttached},
+	"Solidity":         {braces: true, attached: cStyleAttached},
+	"Python": {
+		declaration: regexp.MustCompile(`^(async\s+def|def|class)\b`),
+		attached:    hashAttached,
+	},
+	"Ruby": {
+		declaration: regexp.MustCompile(`^(def|class|module)\b`),
+		attached:    hashAttached,
+	},
+}
+
+// units partitions lines into units that each start at a top-level
+// declaration, pulling the comments and decorators directly above it along
+func (s style) units(lines []string) []unit {
+	var boundaries []boundary
+	depth := 0
+	inComment := false
+	// commented marks the lines that start inside a block comment
+	commented := make([]bool, len(lines))
+	for i, line := range lines {
+		commented[i] = inComment
+		if !inComment && s.startsDeclaration(line, depth) {
+			boundaries = append(boundaries, boundary{line: s.attachedStart(lines, commented, i), symbol: s.lineSymbol(line)})
+		}
+		if s.braces {
+			var delta int
+			delta, inComment = s.braceDelta(line, inComment)
+			depth = max(depth+delta, 0)
+		}
+	}
+	return unitsFromBoundaries(boundaries, len(lines))
+}
+
+func (s style) startsDeclaration(line string, depth int) bool {
+	if depth > 0 || line == "" || line[0] == ' ' || line[0] == '\t' {
+		return false
+	}
+	if s.isAttached(line) {
+		return false
+	}
+	if s.declaration != nil {
+		return s.declaration.MatchString(line)
+	}
+	// A closing brace or parenthesis at column zero ends a declaration
+	// rather than starting one
+	return !strings.ContainsAny(line[:1], "})]")
+}
+
+// attachedStart walks up from a declaration at line i over the comment and
+// decorator lines directly above it
+func (s style) attachedStart(lines []string, commented []bool, i int) int {
+	for i > 0 && (commented[i-1] || s.isAttached(lines[i-1])) {
+		i--
+	}
+	return i
+}
+
+func (s style) isAttached(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range s.attached {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// braceDelta counts the braces a line opens minus those it closes, ignoring
+// braces in string and character literals and in comments. inComment tells
+// whether the line starts inside a block comment; the result tells whether
+// it ends inside one.
+func (s style) braceDelta(line string, inComment bool) (int, bool) {
+	delta := 0
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case inComment:
+			if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+				inComment = false
+				i++
+			}
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' && s.charLiterals:
+			i = charLiteralEnd(line, i)
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return delta, false
+		case c == '/' && i+1 < len(line) && line[i+1] == '*':
+			inComment = true
+			i++
+		case c == '{':
+			delta++
+		case c == '}':
+			delta--
+		}
+	}
+	return delta, inComment
+}
+
+// charLiteralEnd returns the index of the quote closing the character
+// literal opened at line[i], or i itself when the quote opens none, as for
+// a lifetime or label
+func charLiteralEnd(line string, i int) int {
+	rest := line[i+1:]
+	if len(rest) > 1 && rest[0] == '\\' {
+		if end := strings.IndexByte(rest[2:], '\''); end >= 0 {
+			return i + 1 + 2 + end
+		}
+		return i
+	}
+	if _, size := utf8.DecodeRuneInString(rest); size > 0 && strings.HasPrefix(rest[size:], "'") {
+		return i + size + 1
+	}
+	return i
+}
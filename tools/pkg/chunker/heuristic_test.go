@@ -0,0 +1,152 @@
+package chunker
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBraceDelta(t *testing.T) {
+	tests := []struct {
+		name          string
+		language      string
+		line          string
+		inComment     bool
+		want          int
+		wantInComment bool
+	}{
+		{"open", "JavaScript", "function f() {", false, 1, false},
+		{"open and close", "JavaScript", "if (x) { y() }", false, 0, false},
+		{"close", "JavaScript", "}", false, -1, false},
+		{"double-quoted string", "JavaScript", `const s = "{{";`, false, 0, false},
+		{"single-quoted string", "JavaScript", `const s = '{';`, false, 0, false},
+		{"template literal", "JavaScript", "const s = `${x}{`;", false, 0, false},
+		{"escaped quote", "JavaScript", `const s = "\"{";`, false, 0, false},
+		{"line comment", "JavaScript", "x() // {", false, 0, false},
+		{"inline block comment", "JavaScript", "x() /* { */ {", false, 1, false},
+		{"block comment opens", "JavaScript", "/* {", false, 0, true},
+		{"inside block comment", "JavaScript", " * { {", true, 0, true},
+		{"block comment closes", "JavaScript", " * { */ }", true, -1, false},
+		{"lifetime", "Rust", "struct Parser<'a> {", false, 1, false},
+		{"lifetimes", "Rust", "impl<'a, 'b> Parser<'a> {", false, 1, false},
+		{"char literal brace", "Rust", "let c = '{'; {", false, 1, false},
+		{"escaped char literal", "Rust", `let c = '\''; {`, false, 1, false},
+		{"unicode char literal", "Rust", "let c = 'é'; {", false, 1, false},
+		{"trailing quote", "Rust", `let c = '\`, false, 0, false},
+		{"label", "Rust", "'outer: loop {", false, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, inComment := languageStyles[tt.language].braceDelta(tt.line, tt.inComment)
+			if got != tt.want || inComment != tt.wantInComment {
+				t.Errorf("braceDelta(%q, %v) = %d, %v, want %d, %v", tt.line, tt.inComment, got, inComment, tt.want, tt.wantInComment)
+			}
+		})
+	}
+}
+
+func TestStyleUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		content  string
+		// want lists the first line and symbol of each unit after the
+		// leading one
+		want []string
+	}{
+		{
+			name:     "JavaScript functions with comments",
+			language: "JavaScript",
+			content: `import x from "x";
+
+// add sums two numbers
+function add(a, b) {
+  if (a) {
+    return a + b;
+  }
+}
+
+const sub = (a, b) => a - b;
+
+/**
+ * Shape is a thing
+ */
+class Shape {
+  area() { return 0; }
+}`,
+			want: []string{"3 function add", "10 function sub", "12 class Shape"},
+		},
+		{
+			name:     "block comment with braces and column-zero text",
+			language: "C/C++",
+			content: `/*
+Copyright {2024}
+*/
+int main(void) {
+  return 0;
+}`,
+			want: []string{"1 function main"},
+		},
+		{
+			name:     "Rust lifetimes keep the depth",
+			language: "Rust",
+			content: `struct Parser<'a> {
+    input: &'a str,
+}
+
+impl<'a> Parser<'a> {
+    fn next(&mut self) -> char {
+        '}'
+    }
+}
+
+#[derive(Debug)]
+enum Token {
+    Word,
+}
+
+pub fn parse<'a>(input: &'a str) -> Parser<'a> {
+    Parser { input }
+}`,
+			want: []string{"1 struct Parser", "5", "11 enum Token", "16 function parse"},
+		},
+		{
+			name:     "Python decorators and nested defs",
+			language: "Python",
+			content: `import os
+
+@cache
+def load(path):
+    def inner():
+        pass
+    return inner
+
+class Store:
+    def get(self):
+        pass`,
+			want: []string{"3 function load", "9 class Store"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := strings.Split(tt.content, "\n")
+			units := languageStyles[tt.language].units(lines)
+
+			var got []string
+			for _, u := range units {
+				if u.start == 0 && u.symbol == (Symbol{}) {
+					continue
+				}
+				got = append(got, strings.TrimSpace(fmt.Sprintf("%d %s %s", u.start+1, u.symbol.Kind, u.symbol.Name)))
+			}
+			if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Errorf("units = %q, want %q", got, tt.want)
+			}
+			if units[len(units)-1].end != len(lines) {
+				t.Errorf("units end at line %d, want %d", units[len(units)-1].end, len(lines))
+			}
+		})
+	}
+}
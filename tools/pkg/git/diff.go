@@ -0,0 +1,110 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ChangeType describes how a file changed between two commits
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeModified ChangeType = "modified"
+	ChangeDeleted  ChangeType = "deleted"
+	ChangeRenamed  ChangeType = "renamed"
+)
+
+// FileChange is a single entry of a diff between two commits. OldPath is only
+// set for renames.
+type FileChange struct {
+	Type    ChangeType
+	Path    string
+	OldPath string
+}
+
+// HeadCommit returns the commit SHA checked out in repoDir
+func HeadCommit(ctx context.Context, repoDir string) (string, error) {
+	out, err := runGit(ctx, repoDir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// HasCommit reports whether the commit is present in repoDir's object store
+func HasCommit(ctx context.Context, repoDir, sha string) bool {
+	_, err := runGit(ctx, repoDir, "cat-file", "-e", sha+"^{commit}")
+	return err == nil
+}
+
+// DiffFiles lists the files that changed between two commits, with renames
+// detected
+func DiffFiles(ctx context.Context, repoDir, fromSHA, toSHA string) ([]FileChange, error) {
+	out, err := runGit(ctx, repoDir, "diff", "--name-status", "-z", "-M", fromSHA, toSHA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s..%s: %w", fromSHA, toSHA, err)
+	}
+	return parseNameStatus(out)
+}
+
+// parseNameStatus parses the output of git diff --name-status -z. It is a
+// flat list of NUL-terminated fields: the status followed by one path, or
+// two paths for renames and copies.
+func parseNameStatus(out []byte) ([]FileChange, error) {
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	var changes []FileChange
+	for i := 0; i < len(fields); i++ {
+		status := fields[i]
+		if status == "" {
+			continue
+		}
+
+		switch status[0] {
+		case 'R', 'C':
+			if i+2 >= len(fields) {
+				return nil, fmt.Errorf("malformed diff output for status %s", status)
+			}
+			oldPath, newPath := fields[i+1], fields[i+2]
+			i += 2
+			if status[0] == 'R' {
+				changes = append(changes, FileChange{Type: ChangeRenamed, Path: newPath, OldPath: oldPath})
+			} else {
+				changes = append(changes, FileChange{Type: ChangeAdded, Path: newPath})
+			}
+		default:
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("malformed diff output for status %s", status)
+			}
+			path := fields[i+1]
+			i++
+			switch status[0] {
+			case 'A':
+				changes = append(changes, FileChange{Type: ChangeAdded, Path: path})
+			case 'D':
+				changes = append(changes, FileChange{Type: ChangeDeleted, Path: path})
+			default:
+				// M, T (type change) and U (unmerged) all mean new content
+				changes = append(changes, FileChange{Type: ChangeModified, Path: path})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func runGit(ctx context.Context, repoDir string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoDir
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,133 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNameStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		want    []FileChange
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{
+			"added, modified and deleted",
+			"A\x00new.go\x00M\x00main.go\x00D\x00old.go\x00",
+			[]FileChange{
+				{Type: ChangeAdded, Path: "new.go"},
+				{Type: ChangeModified, Path: "main.go"},
+				{Type: ChangeDeleted, Path: "old.go"},
+			},
+			false,
+		},
+		{
+			"rename with similarity score",
+			"R087\x00src/a.go\x00src/b.go\x00M\x00c.go\x00",
+			[]FileChange{
+				{Type: ChangeRenamed, Path: "src/b.go", OldPath: "src/a.go"},
+				{Type: ChangeModified, Path: "c.go"},
+			},
+			false,
+		},
+		{
+			"copy is an addition",
+			"C100\x00a.go\x00b.go\x00",
+			[]FileChange{{Type: ChangeAdded, Path: "b.go"}},
+			false,
+		},
+		{
+			"type change and unmerged are modifications",
+			"T\x00link\x00U\x00conflict.go\x00",
+			[]FileChange{
+				{Type: ChangeModified, Path: "link"},
+				{Type: ChangeModified, Path: "conflict.go"},
+			},
+			false,
+		},
+		{
+			// -z leaves paths unquoted, whatever they contain
+			"paths with tabs, newlines and spaces",
+			"R100\x00dir/old\tname.go\x00dir/new\nname.go\x00A\x00with space.md\x00",
+			[]FileChange{
+				{Type: ChangeRenamed, Path: "dir/new\nname.go", OldPath: "dir/old\tname.go"},
+				{Type: ChangeAdded, Path: "with space.md"},
+			},
+			false,
+		},
+		{"rename missing its new path", "R100\x00a.go\x00", nil, true},
+		{"status missing its path", "M\x00", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNameStatus([]byte(tt.out))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNameStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNameStatus() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gitCommand(t, dir, "init", "--quiet")
+	long := strings.Repeat("a line that keeps the rename similar\n", 20)
+	write("keep.go", "package keep\n")
+	write("edit.go", "package edit\n")
+	write("drop.go", "package drop\n")
+	write("docs/old name.md", long)
+	gitCommand(t, dir, "add", "-A")
+	gitCommand(t, dir, "commit", "--quiet", "-m", "first")
+	from := strings.TrimSpace(gitCommand(t, dir, "rev-parse", "HEAD"))
+
+	write("edit.go", "package edit\n\nfunc Edited() {}\n")
+	write("new.go", "package added\n")
+	os.Remove(filepath.Join(dir, "drop.go"))
+	gitCommand(t, dir, "mv", "docs/old name.md", "docs/new\tname.md")
+	gitCommand(t, dir, "add", "-A")
+	gitCommand(t, dir, "commit", "--quiet", "-m", "second")
+
+	ctx := context.Background()
+	to, err := HeadCommit(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !HasCommit(ctx, dir, from) || HasCommit(ctx, dir, strings.Repeat("0", 40)) {
+		t.Error("HasCommit() does not match the object store")
+	}
+
+	changes, err := DiffFiles(ctx, dir, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileChange{
+		{Type: ChangeRenamed, Path: "docs/new\tname.md", OldPath: "docs/old name.md"},
+		{Type: ChangeDeleted, Path: "drop.go"},
+		{Type: ChangeModified, Path: "edit.go"},
+		{Type: ChangeAdded, Path: "new.go"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("DiffFiles() = %+v, want %+v", changes, want)
+	}
+}
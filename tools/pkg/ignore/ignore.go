@@ -0,0 +1,242 @@
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GitignoreFile is the name of the files Load reads patterns from
+const GitignoreFile = ".gitignore"
+
+// pattern is a single compiled gitignore line
+type pattern struct {
+	// base is the slash-separated directory the pattern was read in,
+	// relative to the tree root, or "" for the root
+	base    string
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// Matcher decides whether slash-separated paths relative to a tree root are
+// ignored, following gitignore rules: the last matching pattern wins, "!"
+// re-includes, a trailing "/" matches directories only, patterns containing
+// a "/" are anchored to their directory and "**" matches across directories.
+type Matcher struct {
+	patterns []pattern
+}
+
+// New returns a Matcher that ignores nothing
+func New() *Matcher {
+	return &Matcher{}
+}
+
+// Compile returns a Matcher for patterns as if they were read from a
+// .gitignore at the tree root. Unlike gitignore files, an invalid pattern is
+// an error.
+func Compile(patterns []string) (*Matcher, error) {
+	m := New()
+	for _, line := range patterns {
+		p, ok, err := parsePattern("", line)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+	return m, nil
+}
+
+// Load reads every .gitignore file under root, skipping .git directories and
+// directories already ignored by the files above them
+func Load(root string) (*Matcher, error) {
+	m := New()
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = ""
+		}
+
+		if info.Name() == ".git" || (rel != "" && m.Ignored(rel, true)) {
+			return filepath.SkipDir
+		}
+
+		// Read the directory's own patterns before any of its entries are
+		// visited
+		return m.addFile(rel, filepath.Join(p, GitignoreFile))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s files: %w", GitignoreFile, err)
+	}
+	return m, nil
+}
+
+// addFile appends the patterns of a gitignore file in directory base.
+// Invalid lines are skipped, as git does.
+func (m *Matcher) addFile(base, file string) error {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if p, ok, err := parsePattern(base, scanner.Text()); err == nil && ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+	return scanner.Err()
+}
+
+// Empty reports whether m has no patterns
+func (m *Matcher) Empty() bool {
+	return m == nil || len(m.patterns) == 0
+}
+
+// Match reports whether the patterns ignore relPath itself, without looking
+// at its parent directories
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+
+	ignored := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		name := relPath
+		if p.base != "" {
+			if !strings.HasPrefix(relPath, p.base+"/") {
+				continue
+			}
+			name = relPath[len(p.base)+1:]
+		}
+		if p.re.MatchString(name) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// Ignored reports whether relPath or any of its parent directories is
+// ignored. As in git, a file cannot be re-included once its directory is
+// ignored.
+func (m *Matcher) Ignored(relPath string, isDir bool) bool {
+	if m.Empty() {
+		return false
+	}
+
+	for dir := path.Dir(relPath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if m.Match(dir, true) {
+			return true
+		}
+	}
+	return m.Match(relPath, isDir)
+}
+
+// parsePattern compiles one gitignore line found in directory base. It
+// returns false for blank lines and comments.
+func parsePattern(base, line string) (pattern, bool, error) {
+	p := pattern{base: base}
+
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return p, false, nil
+	}
+
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return p, false, nil
+	}
+
+	// A slash anywhere but the end anchors the pattern to its directory;
+	// otherwise it matches a name at any depth
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = "**/" + line
+	}
+
+	re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+	if err != nil {
+		return p, false, fmt.Errorf("invalid pattern %q: %w", line, err)
+	}
+	p.re = re
+	return p, true, nil
+}
+
+// globToRegexp translates a gitignore glob to a regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
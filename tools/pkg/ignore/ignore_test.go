@@ -0,0 +1,149 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{"name at any depth", []string{"*.log"}, "a/b/debug.log", false, true},
+		{"name at root", []string{"*.log"}, "debug.log", false, true},
+		{"star stays within a segment", []string{"*.log"}, "debug.log.txt", false, false},
+		{"anchored with leading slash", []string{"/build"}, "build", true, true},
+		{"anchored does not match deeper", []string{"/build"}, "src/build", true, false},
+		{"inner slash anchors", []string{"docs/*.md"}, "docs/a.md", false, true},
+		{"inner slash anchors to root", []string{"docs/*.md"}, "src/docs/a.md", false, false},
+		{"inner slash star stays in segment", []string{"docs/*.md"}, "docs/api/a.md", false, false},
+		{"leading double star", []string{"**/testdata"}, "a/b/testdata", true, true},
+		{"leading double star at root", []string{"**/testdata"}, "testdata", true, true},
+		{"inner double star", []string{"a/**/b"}, "a/x/y/b", false, true},
+		{"inner double star matches zero dirs", []string{"a/**/b"}, "a/b", false, true},
+		{"trailing double star", []string{"vendor/**"}, "vendor/x/y.go", false, true},
+		{"trailing double star not the dir itself", []string{"vendor/**"}, "vendor", true, false},
+		{"dir-only matches directory", []string{"tmp/"}, "a/tmp", true, true},
+		{"dir-only skips file", []string{"tmp/"}, "a/tmp", false, false},
+		{"negation re-includes", []string{"*.md", "!README.md"}, "README.md", false, false},
+		{"negation leaves others", []string{"*.md", "!README.md"}, "CHANGES.md", false, true},
+		{"last match wins", []string{"!README.md", "*.md"}, "README.md", false, true},
+		{"question mark", []string{"file?.go"}, "file1.go", false, true},
+		{"question mark needs one char", []string{"file?.go"}, "file.go", false, false},
+		{"character class", []string{"[ab].go"}, "b.go", false, true},
+		{"negated character class", []string{"[!ab].go"}, "b.go", false, false},
+		{"escaped bang is literal", []string{`\!important`}, "!important", false, true},
+		{"escaped hash is literal", []string{`\#notes`}, "#notes", false, true},
+		{"comment ignored", []string{"# *.go"}, "main.go", false, false},
+		{"trailing spaces trimmed", []string{"*.go   "}, "main.go", false, true},
+		{"dot is literal", []string{"a.go"}, "abgo", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Compile(tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Match(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("Match(%q, %v) with %q = %v, want %v", tt.path, tt.isDir, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIgnoredParentDirectory(t *testing.T) {
+	m, err := Compile([]string{"build/", "!build/keep.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// As in git, a file cannot be re-included once its directory is ignored
+	if !m.Ignored("build/keep.txt", false) {
+		t.Error("Ignored() re-included a file in an ignored directory")
+	}
+	if m.Ignored("src/main.go", false) {
+		t.Error("Ignored() ignored an unrelated file")
+	}
+
+	var nilMatcher *Matcher
+	if !nilMatcher.Empty() || nilMatcher.Ignored("build/x", false) {
+		t.Error("nil Matcher ignores paths")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":                  "*.log\n/dist/\nsecret/\n",
+		"src/.gitignore":              "generated/\n!keep.log\n/local.go\n",
+		"src/main.go":                 "",
+		"src/local.go":                "",
+		"src/pkg/local.go":            "",
+		"secret/.gitignore":           "!*.log\n",
+		".git/.gitignore":             "*.go\n",
+		"docs/guide.md":               "",
+		"src/generated/.gitignore":    "!*\n",
+		"src/generated/types.go":      "",
+		"src/pkg/nested/.gitignore":   "*.md\n",
+		"src/pkg/nested/README.md":    "",
+		"src/pkg/nested/sub/notes.md": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"debug.log", false, true},
+		{"src/debug.log", false, true},
+		{"src/keep.log", false, false},
+		{"src/pkg/keep.log", false, false},
+		{"keep.log", false, true},
+		{"dist", true, true},
+		{"src/dist", true, false},
+		{"src/main.go", false, false},
+		{"src/local.go", false, true},
+		{"src/pkg/local.go", false, false},
+		{"src/generated/types.go", false, true},
+		{"generated/types.go", false, false},
+		{"secret/app.log", false, true},
+		{"docs/guide.md", false, false},
+		{"src/pkg/nested/README.md", false, true},
+		{"src/pkg/nested/sub/notes.md", false, true},
+		{"src/pkg/README.md", false, false},
+		{"main.go", false, false},
+		{".git/hooks.go", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestCompileInvalidPattern(t *testing.T) {
+	if _, err := Compile([]string{"[z-a].go"}); err == nil {
+		t.Error("Compile() accepted an invalid character range")
+	}
+}
@@ -0,0 +1,199 @@
+package language
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// Unknown is the language of files nothing in the registry matches
+const Unknown = "Unknown"
+
+// Language describes how files of one language are recognised
+type Language struct {
+	Name string `json:"name"`
+	// Extensions are lower-case file extensions including the dot
+	Extensions []string `json:"extensions,omitempty"`
+	// Filenames are exact base names, such as "Makefile". A trailing "*"
+	// matches any suffix, as in "Dockerfile.*".
+	Filenames []string `json:"filenames,omitempty"`
+	// Interpreters are the programs named on a "#!" line, without version
+	// suffixes, such as "python" for "#!/usr/bin/env python3"
+	Interpreters []string `json:"interpreters,omitempty"`
+	// Documentation marks prose formats, such as Markdown, as opposed to code
+	Documentation bool `json:"documentation,omitempty"`
+}
+
+// registry lists every known language. Names already stored with indexed
+// chunks, such as "C/C++", must not change.
+var registry = []Language{
+	{Name: "Go", Extensions: []string{".go"}},
+	{Name: "JavaScript", Extensions: []string{".js", ".jsx", ".mjs", ".cjs"}, Interpreters: []string{"node", "nodejs"}},
+	{Name: "TypeScript", Extensions: []string{".ts", ".tsx", ".mts", ".cts"}, Interpreters: []string{"deno", "ts-node"}},
+	{Name: "Python", Extensions: []string{".py", ".pyi", ".pyw"}, Filenames: []string{"SConstruct", "SConscript"}, Interpreters: []string{"python"}},
+	{Name: "Java", Extensions: []string{".java"}},
+	{Name: "C/C++", Extensions: []string{".c", ".cc", ".cpp", ".cxx", ".h", ".hh", ".hpp", ".hxx", ".inl"}},
+	{Name: "C#", Extensions: []string{".cs", ".csx"}},
+	{Name: "Objective-C", Extensions: []string{".m", ".mm"}},
+	{Name: "Ruby", Extensions: []string{".rb", ".rake", ".gemspec"}, Filenames: []string{"Gemfile", "Rakefile", "Vagrantfile", "Podfile"}, Interpreters: []string{"ruby"}},
+	{Name: "PHP", Extensions: []string{".php", ".phtml"}, Interpreters: []string{"php"}},
+	{Name: "Rust", Extensions: []string{".rs"}},
+	{Name: "Kotlin", Extensions: []string{".kt", ".kts"}},
+	{Name: "Swift", Extensions: []string{".swift"}},
+	{Name: "Scala", Extensions: []string{".scala", ".sc"}},
+	{Name: "Groovy", Extensions: []string{".groovy", ".gradle"}, Filenames: []string{"Jenkinsfile"}},
+	{Name: "Dart", Extensions: []string{".dart"}},
+	{Name: "Lua", Extensions: []string{".lua"}, Interpreters: []string{"lua", "luajit"}},
+	{Name: "Perl", Extensions: []string{".pl", ".pm"}, Interpreters: []string{"perl"}},
+	{Name: "R", Extensions: []string{".r"}, Interpreters: []string{"Rscript"}},
+	{Name: "Elixir", Extensions: []string{".ex", ".exs"}, Interpreters: []string{"elixir"}},
+	{Name: "Erlang", Extensions: []string{".erl", ".hrl"}, Interpreters: []string{"escript"}},
+	{Name: "Haskell", Extensions: []string{".hs"}, Interpreters: []string{"runhaskell"}},
+	{Name: "Clojure", Extensions: []string{".clj", ".cljs", ".cljc", ".edn"}},
+	{Name: "Shell", Extensions: []string{".sh", ".bash", ".zsh", ".ksh"}, Interpreters: []string{"sh", "bash", "zsh", "ksh", "dash"}},
+	{Name: "PowerShell", Extensions: []string{".ps1", ".psm1", ".psd1"}, Interpreters: []string{"pwsh"}},
+	{Name: "Batch", Extensions: []string{".bat", ".cmd"}},
+	{Name: "SQL", Extensions: []string{".sql"}},
+	{Name: "HTML", Extensions: []string{".html", ".htm", ".xhtml"}},
+	{Name: "CSS", Extensions: []string{".css"}},
+	{Name: "SCSS", Extensions: []string{".scss", ".sass"}},
+	{Name: "Less", Extensions: []string{".less"}},
+	{Name: "Vue", Extensions: []string{".vue"}},
+	{Name: "Svelte", Extensions: []string{".svelte"}},
+	{Name: "Markdown", Extensions: []string{".md", ".markdown", ".mdx"}, Documentation: true},
+	{Name: "reStructuredText", Extensions: []string{".rst"}, Documentation: true},
+	{Name: "Text", Extensions: []string{".txt"}, Filenames: []string{"LICENSE", "COPYING", "AUTHORS", "CODEOWNERS"}, Documentation: true},
+	{Name: "JSON", Extensions: []string{".json", ".jsonc", ".json5"}},
+	{Name: "YAML", Extensions: []string{".yaml", ".yml"}},
+	{Name: "TOML", Extensions: []string{".toml"}, Filenames: []string{"Cargo.lock", "Pipfile"}},
+	{Name: "XML", Extensions: []string{".xml", ".xsd", ".xsl", ".plist", ".csproj", ".pom"}},
+	{Name: "INI", Extensions: []string{".ini", ".cfg", ".conf", ".properties"}},
+	{Name: "Protocol Buffers", Extensions: []string{".proto"}},
+	{Name: "GraphQL", Extensions: []string{".graphql", ".gql"}},
+	{Name: "Terraform", Extensions: []string{".tf", ".tfvars", ".hcl"}},
+	{Name: "Dockerfile", Extensions: []string{".dockerfile"}, Filenames: []string{"Dockerfile", "Dockerfile.*", "Containerfile"}},
+	{Name: "Makefile", Extensions: []string{".mk", ".mak"}, Filenames: []string{"Makefile", "makefile", "GNUmakefile"}},
+	{Name: "CMake", Extensions: []string{".cmake"}, Filenames: []string{"CMakeLists.txt"}},
+	{Name: "Nix", Extensions: []string{".nix"}},
+	{Name: "Zig", Extensions: []string{".zig"}},
+	{Name: "Julia", Extensions: []string{".jl"}, Interpreters: []string{"julia"}},
+	{Name: "OCaml", Extensions: []string{".ml", ".mli"}},
+	{Name: "F#", Extensions: []string{".fs", ".fsi", ".fsx"}},
+	{Name: "Solidity", Extensions: []string{".sol"}},
+}
+
+var (
+	byName        = make(map[string]Language)
+	byExtension   = make(map[string]string)
+	byFilename    = make(map[string]string)
+	byInterpreter = make(map[string]string)
+	filenameGlobs []struct{ prefix, name string }
+)
+
+func init() {
+	for _, lang := range registry {
+		byName[strings.ToLower(lang.Name)] = lang
+		for _, ext := range lang.Extensions {
+			byExtension[ext] = lang.Name
+		}
+		for _, filename := range lang.Filenames {
+			if prefix, ok := strings.CutSuffix(filename, "*"); ok {
+				filenameGlobs = append(filenameGlobs, struct{ prefix, name string }{prefix, lang.Name})
+			} else {
+				byFilename[filename] = lang.Name
+			}
+		}
+		for _, interpreter := range lang.Interpreters {
+			byInterpreter[interpreter] = lang.Name
+		}
+	}
+}
+
+// Detect returns the language of the file at the slash-separated path,
+// looking at its name first, then its extension and finally the "#!" line
+// at the start of content, which may be empty. It returns Unknown when none
+// of them match.
+func Detect(filePath, content string) string {
+	base := path.Base(filePath)
+	if name, ok := byFilename[base]; ok {
+		return name
+	}
+	for _, glob := range filenameGlobs {
+		if strings.HasPrefix(base, glob.prefix) {
+			return glob.name
+		}
+	}
+	if name, ok := byExtension[strings.ToLower(path.Ext(base))]; ok {
+		return name
+	}
+	if name, ok := fromShebang(content); ok {
+		return name
+	}
+	return Unknown
+}
+
+// fromShebang reads the interpreter from a "#!/usr/bin/env python3" or
+// "#!/bin/sh" line
+func fromShebang(content string) (string, bool) {
+	line, ok := strings.CutPrefix(content, "#!")
+	if !ok {
+		return "", false
+	}
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", false
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		// Skip env options such as -S
+		fields = fields[1:]
+		for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			return "", false
+		}
+		interpreter = path.Base(fields[0])
+	}
+
+	// python3.11 and ruby2 are python and ruby
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	name, ok := byInterpreter[interpreter]
+	return name, ok
+}
+
+// Canonical returns the registered spelling of a language name, matched
+// case-insensitively
+func Canonical(name string) (string, bool) {
+	lang, ok := byName[strings.ToLower(name)]
+	return lang.Name, ok
+}
+
+// IsDocumentation reports whether the named language is a documentation
+// format rather than code
+func IsDocumentation(name string) bool {
+	return byName[strings.ToLower(name)].Documentation
+}
+
+// All returns every registered language sorted by name
+func All() []Language {
+	languages := append([]Language(nil), registry...)
+	sort.Slice(languages, func(i, j int) bool {
+		return strings.ToLower(languages[i].Name) < strings.ToLower(languages[j].Name)
+	})
+	return languages
+}
+
+// Names returns the name of every registered language sorted by name
+func Names() []string {
+	languages := All()
+	names := make([]string, len(languages))
+	for i, lang := range languages {
+		names[i] = lang.Name
+	}
+	return names
+}
@@ -0,0 +1,86 @@
+package language
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		content  string
+		want     string
+	}{
+		{"extension", "cmd/server/main.go", "", "Go"},
+		{"upper-case extension", "src/Main.JAVA", "", "Java"},
+		{"multi-language extension", "include/vec.hpp", "", "C/C++"},
+		{"filename", "Makefile", "", "Makefile"},
+		{"filename in a directory", "build/CMakeLists.txt", "", "CMake"},
+		// The filename wins over the .txt extension
+		{"filename before extension", "CMakeLists.txt", "", "CMake"},
+		{"filename is case-sensitive", "MAKEFILE", "", Unknown},
+		{"glob", "deploy/Dockerfile.prod", "", "Dockerfile"},
+		{"glob prefix alone", "Dockerfile", "", "Dockerfile"},
+		{"extension of a glob language", "prod.Dockerfile", "", "Dockerfile"},
+		{"shebang", "bin/run", "#!/bin/sh\necho hi\n", "Shell"},
+		{"env shebang", "bin/serve", "#!/usr/bin/env node\n", "JavaScript"},
+		{"env options", "bin/serve", "#!/usr/bin/env -S deno run\n", "TypeScript"},
+		{"versioned interpreter", "bin/tool", "#!/usr/bin/python3.11\n", "Python"},
+		{"shebang without newline", "bin/tool", "#!/usr/bin/env ruby", "Ruby"},
+		{"extension before shebang", "tool.py", "#!/bin/bash\n", "Python"},
+		{"empty shebang", "bin/tool", "#!\n", Unknown},
+		{"env alone", "bin/tool", "#!/usr/bin/env\n", Unknown},
+		{"unknown interpreter", "bin/tool", "#!/usr/bin/env fish\n", Unknown},
+		{"shebang not on the first line", "bin/tool", "\n#!/bin/sh\n", Unknown},
+		{"no extension", "README", "plain text", Unknown},
+		{"unknown extension", "data.bin", "", Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.filePath, tt.content); got != tt.want {
+				t.Errorf("Detect(%q) = %q, want %q", tt.filePath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryHasNoHiddenFilenames(t *testing.T) {
+	// The indexer skips hidden files, so such names could never match
+	for _, lang := range registry {
+		for _, filename := range lang.Filenames {
+			if strings.HasPrefix(filename, ".") {
+				t.Errorf("%s lists hidden filename %q", lang.Name, filename)
+			}
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"Go", "Go", true},
+		{"c/c++", "C/C++", true},
+		{"TYPESCRIPT", "TypeScript", true},
+		{"Klingon", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Canonical(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Canonical(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestIsDocumentation(t *testing.T) {
+	for name, want := range map[string]bool{"Markdown": true, "restructuredtext": true, "Text": true, "Go": false, "Unknown": false} {
+		if got := IsDocumentation(name); got != want {
+			t.Errorf("IsDocumentation(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package tokenizer
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// pieces splits text the way byte-pair encoders used by embedding models
+// pre-tokenize it: contractions, words with one leading non-letter, runs of
+// up to three digits, punctuation runs and whitespace. Tokens never cross
+// these pieces.
+var pieces = regexp.MustCompile(`'(?i:[sdmt]|ll|ve|re)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// CountTokens estimates how many tokens a byte-pair encoding model such as
+// OpenAI's cl100k_base splits text into. Without the model's vocabulary the
+// count is an estimate, but one that errs high: common English words are
+// single tokens while this counts one per four letters, and text outside
+// ASCII counts one token per character.
+func CountTokens(text string) int {
+	count := 0
+	for _, piece := range pieces.FindAllString(text, -1) {
+		count += pieceTokens(piece)
+	}
+	return count
+}
+
+func pieceTokens(piece string) int {
+	runes := utf8.RuneCountInString(piece)
+	if runes != len(piece) {
+		// Non-ASCII text rarely merges; count each character
+		return runes
+	}
+
+	switch c := piece[len(piece)-1]; {
+	case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		// Indentation and blank lines merge into long whitespace tokens
+		return ceilDiv(len(piece), 8)
+	case isLetter(c):
+		return ceilDiv(len(piece), 4)
+	case c >= '0' && c <= '9':
+		return 1
+	default:
+		return ceilDiv(len(piece), 2)
+	}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func ceilDiv(n, d int) int {
+	return (n + d - 1) / d
+}
@@ -0,0 +1,56 @@
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		// "hello" and " world" count one token per four letters
+		{"hello world", 4},
+		{"a", 1},
+		// Digits split into runs of three, one token each
+		{"1234567", 3},
+		// Punctuation runs count one token per two characters, and a letter
+		// run may take one punctuation character along
+		{"a := b", 4},
+		{"f(x);", 3},
+		// Contractions split off
+		{"don't", 2},
+		// Indentation merges into whitespace tokens of up to eight
+		{"\t\t\t\treturn", 3},
+		{strings.Repeat(" ", 16), 2},
+		// Text outside ASCII counts each character
+		{"日本語", 3},
+		{"héllo", 5},
+	}
+
+	for _, tt := range tests {
+		if got := CountTokens(tt.text); got != tt.want {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+// TestCountTokensErrsHigh checks the estimate against cl100k_base token
+// counts, which it must not fall below
+func TestCountTokensErrsHigh(t *testing.T) {
+	tests := []struct {
+		text   string
+		cl100k int
+	}{
+		{"hello world", 2},
+		{"The quick brown fox jumps over the lazy dog.", 10},
+	}
+
+	for _, tt := range tests {
+		if got := CountTokens(tt.text); got < tt.cl100k {
+			t.Errorf("CountTokens(%q) = %d, below the model's %d", tt.text, got, tt.cl100k)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrBinaryData is wrapped by the errors DecodeText returns for content that
+// is not text
+var ErrBinaryData = errors.New("binary data")
+
+// binarySniffLength is how much of a file is inspected for control
+// characters
+const binarySniffLength = 8000
+
+// maxControlRatio is the share of control characters above which valid UTF-8
+// is still treated as binary
+const maxControlRatio = 0.1
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// DecodeText returns content as UTF-8 text without a byte order mark.
+// UTF-16 content with a byte order mark is transcoded. Content with NUL
+// bytes, invalid UTF-8 or mostly control characters is rejected with an
+// error wrapping ErrBinaryData that says why.
+func DecodeText(content []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		content = content[len(bomUTF8):]
+	case bytes.HasPrefix(content, bomUTF16LE):
+		return decodeUTF16(content[len(bomUTF16LE):], false)
+	case bytes.HasPrefix(content, bomUTF16BE):
+		return decodeUTF16(content[len(bomUTF16BE):], true)
+	}
+
+	if err := checkText(content); err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// checkText inspects UTF-8 content for signs of binary data
+func checkText(content []byte) error {
+	if bytes.IndexByte(content, 0) >= 0 {
+		return fmt.Errorf("%w: contains NUL bytes", ErrBinaryData)
+	}
+	if !utf8.Valid(content) {
+		return fmt.Errorf("%w: not valid UTF-8", ErrBinaryData)
+	}
+
+	// Control characters are judged on a sample, as binary formats give
+	// themselves away early
+	sample := content
+	if len(sample) > binarySniffLength {
+		sample = sample[:binarySniffLength]
+	}
+
+	controls, runes := 0, 0
+	for _, r := range string(sample) {
+		runes++
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' && r != '\f' && r != '\v' && r != 0x1b {
+			controls++
+		}
+	}
+	if runes > 0 && float64(controls)/float64(runes) > maxControlRatio {
+		return fmt.Errorf("%w: too many control characters", ErrBinaryData)
+	}
+	return nil
+}
+
+// decodeUTF16 transcodes UTF-16 content following its byte order mark
+func decodeUTF16(content []byte, bigEndian bool) (string, error) {
+	if len(content)%2 != 0 {
+		return "", fmt.Errorf("%w: truncated UTF-16", ErrBinaryData)
+	}
+
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(content[2*i])<<8 | uint16(content[2*i+1])
+		} else {
+			units[i] = uint16(content[2*i+1])<<8 | uint16(content[2*i])
+		}
+	}
+
+	text := []byte(string(utf16.Decode(units)))
+	if err := checkText(text); err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
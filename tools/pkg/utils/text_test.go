@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+		wantErr string
+	}{
+		{"ascii", []byte("package main\n"), "package main\n", ""},
+		{"empty", nil, "", ""},
+		{"utf-8 multibyte", []byte("naïve 日本語 🚀"), "naïve 日本語 🚀", ""},
+		{"utf-8 bom", []byte("\xef\xbb\xbfhello"), "hello", ""},
+		{"bom only", []byte("\xef\xbb\xbf"), "", ""},
+		{"utf-16le", []byte("\xff\xfeh\x00i\x00\n\x00"), "hi\n", ""},
+		{"utf-16be", []byte("\xfe\xff\x00h\x00i\x00\n"), "hi\n", ""},
+		{"utf-16le surrogate pair", []byte("\xff\xfe\x3d\xd8\x80\xde"), "🚀", ""},
+		{"utf-16 multibyte", []byte("\xfe\xff\x65\xe5\x67\x2c"), "日本", ""},
+		{"truncated utf-16", []byte("\xff\xfeh\x00i"), "", "truncated UTF-16"},
+		{"utf-16 with nul", []byte("\xff\xfe\x00\x00"), "", "contains NUL bytes"},
+		{"tabs and escapes", []byte("a\tb\r\n\x1b[0m\f"), "a\tb\r\n\x1b[0m\f", ""},
+		{"latin-1", []byte("caf\xe9"), "", "not valid UTF-8"},
+		{"nul bytes", []byte("text\x00more"), "", "contains NUL bytes"},
+		{"control characters", []byte("\x01\x02\x03\x04abcdef"), "", "too many control characters"},
+		{"binary", []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n', 0x00, 0x00}, "", "contains NUL bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeText(tt.content)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrBinaryData) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("DecodeText() error = %v, want binary data error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeText() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeTextSamplesControlCharacters(t *testing.T) {
+	// Control characters past the sniffed prefix do not make text binary
+	content := strings.Repeat("x", binarySniffLength) + strings.Repeat("\x01", binarySniffLength)
+	if _, err := DecodeText([]byte(content)); err != nil {
+		t.Errorf("DecodeText() error = %v", err)
+	}
+}
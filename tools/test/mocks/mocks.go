@@ -4,6 +4,12 @@ import (
 	"errors"
 
 	"mcpserver/internal/models"
+	"mcpserver/internal/storage"
+)
+
+var (
+	_ storage.VectorStore = (*MockPineconeStore)(nil)
+	_ storage.Embedder    = (*MockOpenAIClient)(nil)
 )
 
 // MockPineconeStore provides a mock implementation of the Pinecone store
@@ -29,7 +35,16 @@ func (m *MockPineconeStore) Store(chunk models.CodeChunk) error {
 	return nil
 }
 
-func (m *MockPineconeStore) Search(query []float32, repository, branch string, limit int) ([]models.CodeChunk, error) {
+func (m *MockPineconeStore) StoreBatch(chunks []models.CodeChunk) error {
+	for _, chunk := range chunks {
+		if err := m.Store(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockPineconeStore) Search(query []float32, filter storage.SearchFilter, limit int) ([]models.CodeChunk, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -44,14 +59,32 @@ func (m *MockPineconeStore) Search(query []float32, repository, branch string, l
 		{
 			Content:    "test content",
 			FilePath:   "test/path.go",
-			Repository: repository,
-			Branch:     branch,
+			Repository: filter.Repository,
+			Branch:     filter.Branch,
 			Language:   "Go",
 			Embedding:  []float32{0.1, 0.2, 0.3},
 		},
 	}, nil
 }
 
+func (m *MockPineconeStore) DeleteByFilter(filter storage.DeleteFilter) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	return 0, nil
+}
+
+func (m *MockPineconeStore) Delete(ids []string) error {
+	return m.err
+}
+
+func (m *MockPineconeStore) List(prefix string) ([]string, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []string{}, nil
+}
+
 // MockOpenAIClient provides a mock implementation of the OpenAI client
 type MockOpenAIClient struct {
 	err error
@@ -75,6 +108,26 @@ func (m *MockOpenAIClient) GetEmbedding(text string) ([]float32, error) {
 	return []float32{0.1, 0.2, 0.3}, nil
 }
 
+func (m *MockOpenAIClient) GetEmbeddings(texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embedding, err := m.GetEmbedding(text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+func (m *MockOpenAIClient) MaxInputTokens() int {
+	return 8191
+}
+
+func (m *MockOpenAIClient) Model() string {
+	return "mock"
+}
+
 func (m *MockOpenAIClient) GenerateEnhancedSummary(chunks []map[string]interface{}, query string) (string, error) {
 	if m.err != nil {
 		return "", m.err
@@ -87,4 +140,4 @@ func (m *MockOpenAIClient) GenerateSummary(chunks []models.CodeChunk, query stri
 		return "", m.err
 	}
 	return "Test summary response", nil
-}
\ No newline at end of file
+}